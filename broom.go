@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"net/textproto"
+	"net/url"
 	"os"
 	"os/exec"
 	"slices"
@@ -32,20 +33,29 @@ type Result struct {
 }
 
 // Authenticate authenticates the given request.
+//
+// The oauth2-auth-code auth type returns ErrLoginRequired if the user
+// hasn't logged in yet (via Login), or if their session has expired.
 func Authenticate(req *http.Request, cfg AuthConfig) error {
-	if cfg.Credentials == "" && cfg.Command == "" {
+	if !cfg.hasCredentials() {
 		return nil
 	}
-	credentials := cfg.Credentials
-	if cfg.Command != "" {
-		var err error
-		credentials, err = RunCommand(cfg.Command)
-		if err != nil {
-			return fmt.Errorf("run command: %w", err)
+	if cfg.Type == "oauth2-client-credentials" || cfg.Type == "oauth2-auth-code" {
+		// The credentials (client secret) are only needed when requesting a new token.
+		tokenFunc := oauth2ClientCredentialsToken
+		if cfg.Type == "oauth2-auth-code" {
+			tokenFunc = oauth2AuthCodeToken
 		}
-		if credentials == "" {
-			return fmt.Errorf("run command: no credentials received")
+		token, err := tokenFunc(cfg)
+		if err != nil {
+			return fmt.Errorf("fetch token: %w", err)
 		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+		return nil
+	}
+	credentials, err := cfg.credentials()
+	if err != nil {
+		return err
 	}
 
 	switch cfg.Type {
@@ -60,6 +70,20 @@ func Authenticate(req *http.Request, cfg AuthConfig) error {
 			key = "X-API-Key"
 		}
 		req.Header.Set(key, credentials)
+	case "api-key-cookie":
+		if cfg.APIKeyCookie == "" {
+			return errors.New("API key cookie not specified")
+		}
+		req.AddCookie(&http.Cookie{Name: cfg.APIKeyCookie, Value: credentials})
+	case "api-key-query":
+		if cfg.APIKeyQuery == "" {
+			return errors.New("API key query parameter not specified")
+		}
+		// Appended instead of re-encoding the query, to preserve the existing order.
+		if req.URL.RawQuery != "" {
+			req.URL.RawQuery += "&"
+		}
+		req.URL.RawQuery += url.QueryEscape(cfg.APIKeyQuery) + "=" + url.QueryEscape(credentials)
 	case "":
 		return errors.New("auth type not specified")
 	default:
@@ -69,15 +93,96 @@ func Authenticate(req *http.Request, cfg AuthConfig) error {
 	return nil
 }
 
+// hasCredentials returns whether the auth config provides credentials.
+//
+// The client secret is optional for the oauth2-auth-code auth type,
+// since the user provides their credentials by logging in.
+func (cfg AuthConfig) hasCredentials() bool {
+	if cfg.Type == "oauth2-auth-code" {
+		return cfg.ClientID != ""
+	}
+	return cfg.Credentials != "" || cfg.Command != ""
+}
+
+// credentials returns the auth credentials, running the auth command if specified.
+func (cfg AuthConfig) credentials() (string, error) {
+	if cfg.Command == "" {
+		return cfg.Credentials, nil
+	}
+	credentials, err := RunCommand(cfg.Command)
+	if err != nil {
+		return "", fmt.Errorf("run command: %w", err)
+	}
+	if credentials == "" {
+		return "", fmt.Errorf("run command: no credentials received")
+	}
+
+	return credentials, nil
+}
+
+// ProvidesParameter returns whether the given parameter is populated by Authenticate.
+//
+// Used to avoid requiring values for parameters that carry credentials.
+func (cfg AuthConfig) ProvidesParameter(p Parameter) bool {
+	if !cfg.hasCredentials() {
+		return false
+	}
+	switch cfg.Type {
+	case "bearer", "basic", "oauth2-client-credentials", "oauth2-auth-code":
+		return p.In == "header" && strings.EqualFold(p.Name, "Authorization")
+	case "api-key":
+		key := cfg.APIKeyHeader
+		if key == "" {
+			key = "X-API-Key"
+		}
+		return p.In == "header" && strings.EqualFold(p.Name, key)
+	case "api-key-cookie":
+		return p.In == "cookie" && p.Name == cfg.APIKeyCookie
+	case "api-key-query":
+		return p.In == "query" && p.Name == cfg.APIKeyQuery
+	}
+
+	return false
+}
+
 // AuthTypes returns a list of supported authentication types.
 func AuthTypes() []string {
-	return []string{"bearer", "basic", "api-key"}
+	return []string{"bearer", "basic", "api-key", "api-key-cookie", "api-key-query", "oauth2-client-credentials", "oauth2-auth-code"}
+}
+
+// RedactURL returns the given URL with the credentials removed.
+//
+// Used to avoid leaking API keys sent via the query string (the
+// api-key-query auth type) when displaying the request URL.
+func RedactURL(u *url.URL, cfgs ...AuthConfig) string {
+	var names []string
+	for _, cfg := range cfgs {
+		if cfg.Type == "api-key-query" && cfg.APIKeyQuery != "" {
+			names = append(names, cfg.APIKeyQuery)
+		}
+	}
+	if len(names) == 0 || u.RawQuery == "" {
+		return u.String()
+	}
+	redacted := *u
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		if unescapedName, err := url.QueryUnescape(name); err == nil && slices.Contains(names, unescapedName) {
+			params[i] = name + "=REDACTED"
+		}
+	}
+	redacted.RawQuery = strings.Join(params, "&")
+
+	return redacted.String()
 }
 
 // Execute performs the given HTTP request and returns the result.
 //
-// The output consists of the request body (pretty-printed if JSON),
+// The output consists of the response body (pretty-printed if JSON),
 // and optionally the status code and headers (when "verbose" is true).
+// HEAD responses have no body, so their status code and headers are
+// always included.
 func Execute(req *http.Request, verbose bool) (Result, error) {
 	httpClient := &http.Client{}
 	resp, err := httpClient.Do(req)
@@ -90,11 +195,13 @@ func Execute(req *http.Request, verbose bool) (Result, error) {
 		return Result{}, err
 	}
 	sb := strings.Builder{}
-	if verbose {
+	if verbose || req.Method == http.MethodHead {
 		sb.WriteString(resp.Status)
 		sb.WriteByte('\n')
 		writeHeaders(&sb, resp.Header, nil)
-		sb.WriteByte('\n')
+		if len(body) > 0 {
+			sb.WriteByte('\n')
+		}
 	}
 	if IsJSON(resp.Header.Get("Content-Type")) {
 		body = PrettyJSON(body)
@@ -4,7 +4,11 @@
 package broom_test
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/bojanz/broom"
@@ -76,6 +80,63 @@ func TestAuthenticate(t *testing.T) {
 		t.Errorf(`got %q, want %q`, got, want)
 	}
 
+	// API key, cookie.
+	req, _ = http.NewRequest("GET", "/test", nil)
+	err = broom.Authenticate(req, broom.AuthConfig{
+		Credentials:  "MYKEY",
+		Type:         "api-key-cookie",
+		APIKeyCookie: "api_key",
+	})
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	cookie, err := req.Cookie("api_key")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	} else if cookie.Value != "MYKEY" {
+		t.Errorf(`got %q, want "MYKEY"`, cookie.Value)
+	}
+
+	// API key, cookie not specified.
+	req, _ = http.NewRequest("GET", "/test", nil)
+	err = broom.Authenticate(req, broom.AuthConfig{
+		Credentials: "MYKEY",
+		Type:        "api-key-cookie",
+	})
+	if err == nil {
+		t.Error("expected Authenticate() to return an error")
+	} else if err.Error() != "API key cookie not specified" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// API key, query parameter.
+	req, _ = http.NewRequest("GET", "/test?page=2", nil)
+	err = broom.Authenticate(req, broom.AuthConfig{
+		Credentials: "MY KEY",
+		Type:        "api-key-query",
+		APIKeyQuery: "api_key",
+	})
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	got = req.URL.RawQuery
+	want = "page=2&api_key=MY+KEY"
+	if got != want {
+		t.Errorf(`got %q, want %q`, got, want)
+	}
+
+	// API key, query parameter not specified.
+	req, _ = http.NewRequest("GET", "/test", nil)
+	err = broom.Authenticate(req, broom.AuthConfig{
+		Credentials: "MYKEY",
+		Type:        "api-key-query",
+	})
+	if err == nil {
+		t.Error("expected Authenticate() to return an error")
+	} else if err.Error() != "API key query parameter not specified" {
+		t.Errorf("unexpected error %v", err)
+	}
+
 	// Basic auth.
 	req, _ = http.NewRequest("GET", "/test", nil)
 	err = broom.Authenticate(req, broom.AuthConfig{
@@ -107,6 +168,209 @@ func TestAuthenticate(t *testing.T) {
 	}
 }
 
+func TestAuthenticate_OAuth2ClientCredentials(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	requests := 0
+	expiresIn := 3600
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "my-client" || clientSecret != "MYSECRET" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_client", "error_description": "Unknown client."}`))
+			return
+		}
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "read write" || r.PostForm.Get("audience") != "https://my-api.io" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_request"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if expiresIn == 0 {
+			fmt.Fprintf(w, `{"access_token": "TOKEN%d", "token_type": "Bearer"}`, requests)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "TOKEN%d", "token_type": "Bearer", "expires_in": %d}`, requests, expiresIn)
+	}))
+	defer server.Close()
+	authCfg := broom.AuthConfig{
+		Credentials: "MYSECRET",
+		Type:        "oauth2-client-credentials",
+		TokenURL:    server.URL + "/token",
+		ClientID:    "my-client",
+		Scopes:      []string{"read", "write"},
+		Audience:    "https://my-api.io",
+	}
+
+	// Initial token request.
+	req, _ := http.NewRequest("GET", "/test", nil)
+	err := broom.Authenticate(req, authCfg)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	got := req.Header.Get("Authorization")
+	want := "Bearer TOKEN1"
+	if got != want {
+		t.Errorf(`got %q, want %q`, got, want)
+	}
+
+	// Cached token.
+	req, _ = http.NewRequest("GET", "/test", nil)
+	err = broom.Authenticate(req, authCfg)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	got = req.Header.Get("Authorization")
+	if got != want {
+		t.Errorf(`got %q, want %q`, got, want)
+	}
+	if requests != 1 {
+		t.Errorf("got %v token requests, want 1", requests)
+	}
+
+	// Tokens about to expire are replaced.
+	// A different token URL is used to skip the token cached above.
+	expiresIn = 10
+	authCfg.TokenURL = server.URL + "/token?v=2"
+	for i := 0; i < 2; i++ {
+		req, _ = http.NewRequest("GET", "/test", nil)
+		err = broom.Authenticate(req, authCfg)
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	got = req.Header.Get("Authorization")
+	want = "Bearer TOKEN3"
+	if got != want {
+		t.Errorf(`got %q, want %q`, got, want)
+	}
+
+	// Tokens without an expiry are cached too.
+	expiresIn = 0
+	authCfg.TokenURL = server.URL + "/token?v=3"
+	for i := 0; i < 2; i++ {
+		req, _ = http.NewRequest("GET", "/test", nil)
+		err = broom.Authenticate(req, authCfg)
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+		got = req.Header.Get("Authorization")
+		want = "Bearer TOKEN4"
+		if got != want {
+			t.Errorf(`got %q, want %q`, got, want)
+		}
+	}
+	if requests != 4 {
+		t.Errorf("got %v token requests, want 4", requests)
+	}
+
+	// Invalid client.
+	authCfg.ClientID = "unknown-client"
+	req, _ = http.NewRequest("GET", "/test", nil)
+	err = broom.Authenticate(req, authCfg)
+	if err == nil {
+		t.Error("expected Authenticate() to return an error")
+	}
+	wantErr := "fetch token: token request failed: invalid_client: Unknown client."
+	if err != nil && err.Error() != wantErr {
+		t.Errorf("got %q, want %q", err.Error(), wantErr)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("X-Test", "yes")
+		w.Write([]byte("Hello"))
+	}))
+	defer server.Close()
+
+	// GET.
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	result, err := broom.Execute(req, false)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("got %v, want %v", result.StatusCode, http.StatusOK)
+	}
+	if result.Output != "Hello" {
+		t.Errorf(`got %q, want "Hello"`, result.Output)
+	}
+
+	// GET, verbose.
+	req, _ = http.NewRequest("GET", server.URL, nil)
+	result, err = broom.Execute(req, true)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if !strings.HasPrefix(result.Output, "200 OK\n") || !strings.HasSuffix(result.Output, "\n\nHello") {
+		t.Errorf("unexpected output %q", result.Output)
+	}
+
+	// HEAD, always includes the status and headers.
+	req, _ = http.NewRequest("HEAD", server.URL, nil)
+	result, err = broom.Execute(req, false)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if !strings.HasPrefix(result.Output, "200 OK\n") || !strings.Contains(result.Output, "yes\n") {
+		t.Errorf("unexpected output %q", result.Output)
+	}
+	if strings.HasSuffix(result.Output, "\n\n") {
+		t.Errorf("unexpected trailing newline in %q", result.Output)
+	}
+}
+
+func TestAuthConfig_ProvidesParameter(t *testing.T) {
+	tests := []struct {
+		cfg   broom.AuthConfig
+		param broom.Parameter
+		want  bool
+	}{
+		{broom.AuthConfig{Type: "bearer"}, broom.Parameter{In: "header", Name: "Authorization"}, false},
+		{broom.AuthConfig{Credentials: "MYKEY", Type: "bearer"}, broom.Parameter{In: "header", Name: "authorization"}, true},
+		{broom.AuthConfig{Credentials: "MYKEY", Type: "bearer"}, broom.Parameter{In: "header", Name: "X-API-Key"}, false},
+		{broom.AuthConfig{Credentials: "MYKEY", Type: "api-key"}, broom.Parameter{In: "header", Name: "X-API-Key"}, true},
+		{broom.AuthConfig{Credentials: "MYKEY", Type: "api-key", APIKeyHeader: "X-MyApp-Key"}, broom.Parameter{In: "header", Name: "X-API-Key"}, false},
+		{broom.AuthConfig{Credentials: "MYKEY", Type: "api-key-cookie", APIKeyCookie: "api_key"}, broom.Parameter{In: "cookie", Name: "api_key"}, true},
+		{broom.AuthConfig{Credentials: "MYKEY", Type: "api-key-query", APIKeyQuery: "api_key"}, broom.Parameter{In: "query", Name: "api_key"}, true},
+		{broom.AuthConfig{Credentials: "MYKEY", Type: "api-key-query", APIKeyQuery: "api_key"}, broom.Parameter{In: "header", Name: "api_key"}, false},
+		{broom.AuthConfig{Credentials: "MYSECRET", Type: "oauth2-client-credentials"}, broom.Parameter{In: "header", Name: "Authorization"}, true},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := tt.cfg.ProvidesParameter(tt.param)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		cfg  broom.AuthConfig
+		want string
+	}{
+		{"https://my-api.io/products?api_key=MYKEY", broom.AuthConfig{}, "https://my-api.io/products?api_key=MYKEY"},
+		{"https://my-api.io/products", broom.AuthConfig{Type: "api-key-query", APIKeyQuery: "api_key"}, "https://my-api.io/products"},
+		{"https://my-api.io/products?page=2&api_key=MYKEY", broom.AuthConfig{Type: "api-key-query", APIKeyQuery: "api_key"}, "https://my-api.io/products?page=2&api_key=REDACTED"},
+		{"https://my-api.io/products?my%20key=MYKEY&page=2", broom.AuthConfig{Type: "api-key-query", APIKeyQuery: "my key"}, "https://my-api.io/products?my%20key=REDACTED&page=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			got := broom.RedactURL(u, tt.cfg)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsJSON(t *testing.T) {
 	tests := []struct {
 		mediaType string
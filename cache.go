@@ -0,0 +1,217 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IsURL checks whether the given spec location is a remote URL.
+func IsURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// CacheDir returns the directory used for storing Broom's cached data.
+//
+// The directory is located inside the user's cache directory
+// (e.g. ~/.cache/broom on Linux) and is created if missing.
+func CacheDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(userCacheDir, "broom")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// specCacheEntry represents the metadata of a cached remote specification.
+type specCacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+// fetchSpec fetches a remote specification, using a local cache.
+//
+// The cached specification is revalidated using ETag/If-Modified-Since,
+// and used as-is if the server cannot be reached, allowing offline use.
+func fetchSpec(specURL string) ([]byte, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("cache dir: %w", err)
+	}
+	hash := sha256.Sum256([]byte(specURL))
+	key := hex.EncodeToString(hash[:])
+	specFilename := filepath.Join(dir, "specs", key)
+	entryFilename := specFilename + ".json"
+
+	cached, cachedErr := os.ReadFile(specFilename)
+	entry := specCacheEntry{}
+	if cachedErr == nil {
+		if b, err := os.ReadFile(entryFilename); err == nil {
+			json.Unmarshal(b, &entry)
+		}
+	}
+
+	b, newEntry, err := downloadSpec(specURL, entry, cachedErr == nil)
+	if err != nil {
+		if cachedErr == nil {
+			// The server is down or unreachable, fall back to the cached copy.
+			return cached, nil
+		}
+		return nil, err
+	}
+	if b == nil {
+		// Not modified.
+		return cached, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(specFilename), 0755); err != nil {
+		return nil, fmt.Errorf("cache spec: %w", err)
+	}
+	if err := os.WriteFile(specFilename, b, 0644); err != nil {
+		return nil, fmt.Errorf("cache spec: %w", err)
+	}
+	entryJSON, _ := json.Marshal(newEntry)
+	if err := os.WriteFile(entryFilename, entryJSON, 0644); err != nil {
+		return nil, fmt.Errorf("cache spec: %w", err)
+	}
+
+	return b, nil
+}
+
+// downloadSpec downloads a remote specification.
+//
+// When revalidating a cached copy, a nil body is returned if
+// the server reports that the specification was not modified.
+func downloadSpec(specURL string, entry specCacheEntry, revalidate bool) ([]byte, specCacheEntry, error) {
+	req, err := http.NewRequest(http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, specCacheEntry{}, err
+	}
+	if revalidate {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, specCacheEntry{}, err
+	}
+	defer resp.Body.Close()
+	if revalidate && resp.StatusCode == http.StatusNotModified {
+		return nil, entry, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, specCacheEntry{}, fmt.Errorf("fetch %v: %v", specURL, resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, specCacheEntry{}, err
+	}
+	if len(b) == 0 {
+		return nil, specCacheEntry{}, fmt.Errorf("fetch %v: empty response", specURL)
+	}
+	newEntry := specCacheEntry{
+		URL:          specURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	return b, newEntry, nil
+}
+
+// operationsCacheEntry represents the cached contents of a specification.
+type operationsCacheEntry struct {
+	Version  string `json:"version"`
+	SpecHash string `json:"spec_hash"`
+	SpecContents
+}
+
+// LoadCachedOperations loads available operations from a specification,
+// using a local cache to avoid re-parsing unchanged specifications.
+//
+// The cache is invalidated when the contents of the specification change,
+// or when a different Broom version is used.
+func LoadCachedOperations(filename string) (Operations, error) {
+	contents, err := LoadCachedSpecContents(filename)
+	return contents.Operations, err
+}
+
+// LoadCachedSpecContents loads the operations, tags, and servers defined by
+// a specification, using the same local cache as LoadCachedOperations.
+func LoadCachedSpecContents(filename string) (SpecContents, error) {
+	b, err := readSpec(filename)
+	if err != nil {
+		return SpecContents{}, fmt.Errorf("load spec: %w", err)
+	}
+	hash := sha256.Sum256(b)
+	specHash := hex.EncodeToString(hash[:])
+	cacheFilename, err := operationsCacheFilename(filename)
+	if err != nil {
+		// Caching is an optimization, it's fine to proceed without it.
+		return loadSpecContents(filename, b)
+	}
+	if cached, err := os.ReadFile(cacheFilename); err == nil {
+		entry := operationsCacheEntry{}
+		if err := json.Unmarshal(cached, &entry); err == nil {
+			if entry.Version == Version && entry.SpecHash == specHash {
+				return entry.SpecContents, nil
+			}
+		}
+	}
+
+	contents, err := loadSpecContents(filename, b)
+	if err != nil {
+		return SpecContents{}, err
+	}
+	entry := operationsCacheEntry{
+		Version:      Version,
+		SpecHash:     specHash,
+		SpecContents: contents,
+	}
+	if entryJSON, err := json.Marshal(entry); err == nil {
+		if err := os.MkdirAll(filepath.Dir(cacheFilename), 0755); err == nil {
+			os.WriteFile(cacheFilename, entryJSON, 0644)
+		}
+	}
+
+	return contents, nil
+}
+
+// operationsCacheFilename returns the name of the operations cache file for the given spec.
+//
+// Each spec has a single cache file, overwritten when the spec changes.
+func operationsCacheFilename(filename string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	if !IsURL(filename) {
+		filename, err = filepath.Abs(filename)
+		if err != nil {
+			return "", err
+		}
+	}
+	hash := sha256.Sum256([]byte(filename))
+	key := hex.EncodeToString(hash[:])
+
+	return filepath.Join(dir, "operations", key+".json"), nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/bojanz/broom"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsURL(t *testing.T) {
+	tests := []struct {
+		location string
+		want     bool
+	}{
+		{"", false},
+		{"openapi.yaml", false},
+		{"testdata/openapi3.yaml", false},
+		{"/home/user/openapi.yaml", false},
+		{"http://my-api.io/openapi.yaml", true},
+		{"https://my-api.io/openapi.yaml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := broom.IsURL(tt.location)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSpec_Remote(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	spec, err := os.ReadFile("testdata/openapi3.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := 0
+	revalidated := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidated++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(spec)
+	}))
+	specURL := server.URL + "/openapi3.yaml"
+
+	// Initial fetch.
+	doc, err := broom.LoadSpec(specURL)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if doc.Info.Title != "Product API" {
+		t.Errorf(`got %q, want "Product API"`, doc.Info.Title)
+	}
+
+	// Revalidation.
+	doc, err = broom.LoadSpec(specURL)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if doc.Info.Title != "Product API" {
+		t.Errorf(`got %q, want "Product API"`, doc.Info.Title)
+	}
+	if requests != 2 || revalidated != 1 {
+		t.Errorf("got %v requests, %v revalidated, want 2, 1", requests, revalidated)
+	}
+
+	// Offline.
+	server.Close()
+	ops, err := broom.LoadOperations(specURL)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, ok := ops.ByID("list-products"); !ok {
+		t.Error("expected list-products to be found")
+	}
+
+	// Offline, not cached.
+	_, err = broom.LoadSpec(server.URL + "/missing.yaml")
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestLoadCachedOperations(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	filename := t.TempDir() + "/openapi3.yaml"
+	spec, err := os.ReadFile("testdata/openapi3.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filename, spec, 0644)
+
+	wantOps, err := broom.LoadOperations(filename)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// Uncached.
+	gotOps, err := broom.LoadCachedOperations(filename)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if diff := cmp.Diff(wantOps, gotOps); diff != "" {
+		t.Errorf("operation mismatch (-want +got):\n%s", diff)
+	}
+	// Cached.
+	gotOps, err = broom.LoadCachedOperations(filename)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if diff := cmp.Diff(wantOps, gotOps); diff != "" {
+		t.Errorf("operation mismatch (-want +got):\n%s", diff)
+	}
+
+	wantContents, err := broom.LoadSpecContents(filename)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	gotContents, err := broom.LoadCachedSpecContents(filename)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if diff := cmp.Diff(wantContents, gotContents); diff != "" {
+		t.Errorf("contents mismatch (-want +got):\n%s", diff)
+	}
+
+	// Changing the spec must invalidate the cache.
+	spec = bytes.ReplaceAll(spec, []byte("operationId: list-products"), []byte("operationId: get-products"))
+	os.WriteFile(filename, spec, 0644)
+	gotOps, err = broom.LoadCachedOperations(filename)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, ok := gotOps.ByID("list-products"); ok {
+		t.Error("expected list-products to not be found")
+	}
+	if _, ok := gotOps.ByID("get-products"); !ok {
+		t.Error("expected get-products to be found")
+	}
+}
@@ -22,12 +22,21 @@ func addCmd(args []string) {
 	authTypes := broom.AuthTypes()
 	flags := flag.NewFlagSet("add", flag.ContinueOnError)
 	var (
-		help            = flags.BoolP("help", "h", false, "Display this help text and exit")
-		authCredentials = flags.String("auth", "", "Auth credentials (e.g. access token or API key). Used to authenticate every request")
-		authCommand     = flags.String("auth-cmd", "", "Auth command. Executed on every request to retrieve auth credentials")
-		authType        = flags.String("auth-type", "", fmt.Sprintf("Auth type. One of: %v. Defaults to %v", strings.Join(authTypes, ", "), authTypes[0]))
-		apiKeyHeader    = flags.String("api-key-header", "", "API key header. Defaults to X-API-Key")
-		serverURL       = flags.String("server-url", "", "Server URL")
+		help             = flags.BoolP("help", "h", false, "Display this help text and exit")
+		authCredentials  = flags.String("auth", "", "Auth credentials (e.g. access token, API key, or OAuth2 client secret). Used to authenticate every request")
+		authCommand      = flags.String("auth-cmd", "", "Auth command. Executed on every request to retrieve auth credentials")
+		authType         = flags.String("auth-type", "", fmt.Sprintf("Auth type. One of: %v. Defaults to %v", strings.Join(authTypes, ", "), authTypes[0]))
+		apiKeyHeader     = flags.String("api-key-header", "", "API key header. Defaults to X-API-Key")
+		apiKeyCookie     = flags.String("api-key-cookie", "", "API key cookie. Required by the api-key-cookie auth type")
+		apiKeyQuery      = flags.String("api-key-query", "", "API key query parameter. Required by the api-key-query auth type")
+		tokenURL         = flags.String("token-url", "", "OAuth2 token URL. Required by the OAuth2 auth types")
+		clientID         = flags.String("client-id", "", "OAuth2 client ID. Required by the OAuth2 auth types")
+		scopes           = flags.StringSlice("scopes", nil, "OAuth2 scopes, comma separated")
+		audience         = flags.String("audience", "", "OAuth2 audience. Required by some providers (e.g. Auth0)")
+		authorizationURL = flags.String("authorization-url", "", "OAuth2 authorization URL. Required by the oauth2-auth-code auth type")
+		redirectURL      = flags.String("redirect-url", "", "OAuth2 redirect URL, on localhost. Defaults to http://127.0.0.1:8085/callback")
+		serverURL        = flags.String("server-url", "", "Server URL")
+		serverVars       = flags.StringArray("server-var", nil, "Server variable, in the name=value format. Can be used multiple times")
 	)
 	flags.SortFlags = false
 	if err := flags.Parse(args); err != nil {
@@ -41,11 +50,18 @@ func addCmd(args []string) {
 	if *authType != "" && !slices.Contains(authTypes, *authType) {
 		exitWithError(fmt.Errorf("unrecognized auth type %q", *authType))
 	}
+	serverVariables, err := broom.ParseServerVariables(*serverVars)
+	if err != nil {
+		exitWithError(err)
+	}
 
 	profile := flags.Arg(1)
-	filename := filepath.Clean(flags.Arg(2))
+	filename := flags.Arg(2)
+	if !broom.IsURL(filename) {
+		filename = filepath.Clean(filename)
+	}
 	// Ensure a profile name doesn't conflict with a command name.
-	if profile == "add" || profile == "rm" || profile == "version" {
+	if profile == "add" || profile == "diff" || profile == "lint" || profile == "rm" || profile == "version" {
 		exitWithError(fmt.Errorf("can't name a profile %q, please choose a different name", profile))
 	}
 	// Confirm that the specification exists and is valid.
@@ -56,39 +72,124 @@ func addCmd(args []string) {
 	}
 	specAuthType := authTypes[0]
 	specAPIKeyHeader := ""
+	specAPIKeyCookie := ""
+	specAPIKeyQuery := ""
+	specTokenURL := ""
+	specAuthorizationURL := ""
+	specAuthScheme := ""
 	if spec.Components != nil {
-		for pair := orderedmap.First(spec.Components.SecuritySchemes); pair != nil; pair = pair.Next() {
+		for pair := orderedmap.First(spec.Components.SecuritySchemes); pair != nil && specAuthScheme == ""; pair = pair.Next() {
 			securityScheme := pair.Value()
-			if securityScheme.Type == "http" && securityScheme.Scheme == "bearer" {
+			switch {
+			case securityScheme.Type == "http" && securityScheme.Scheme == "bearer":
 				specAuthType = "bearer"
-				break
-			} else if securityScheme.Type == "http" && securityScheme.Scheme == "basic" {
+			case securityScheme.Type == "http" && securityScheme.Scheme == "basic":
 				specAuthType = "basic"
-				break
-			} else if securityScheme.Type == "apiKey" && securityScheme.In == "header" {
+			case securityScheme.Type == "apiKey" && securityScheme.In == "header":
 				specAuthType = "api-key"
 				specAPIKeyHeader = securityScheme.Name
-				break
+			case securityScheme.Type == "apiKey" && securityScheme.In == "cookie":
+				specAuthType = "api-key-cookie"
+				specAPIKeyCookie = securityScheme.Name
+			case securityScheme.Type == "apiKey" && securityScheme.In == "query":
+				specAuthType = "api-key-query"
+				specAPIKeyQuery = securityScheme.Name
+			case securityScheme.Type == "oauth2" && securityScheme.Flows != nil && *clientID == "" &&
+				(securityScheme.Flows.ClientCredentials != nil || securityScheme.Flows.AuthorizationCode != nil):
+				// Without a client ID, the access token is provided directly (e.g. via --auth-cmd).
+				specAuthType = "bearer"
+			case securityScheme.Type == "oauth2" && securityScheme.Flows != nil && securityScheme.Flows.ClientCredentials != nil:
+				specAuthType = "oauth2-client-credentials"
+				specTokenURL = securityScheme.Flows.ClientCredentials.TokenUrl
+			case securityScheme.Type == "oauth2" && securityScheme.Flows != nil && securityScheme.Flows.AuthorizationCode != nil:
+				specAuthType = "oauth2-auth-code"
+				specTokenURL = securityScheme.Flows.AuthorizationCode.TokenUrl
+				specAuthorizationURL = securityScheme.Flows.AuthorizationCode.AuthorizationUrl
+			default:
+				continue
 			}
+			specAuthScheme = pair.Key()
 		}
 	}
 	if *serverURL == "" && len(spec.Servers) > 0 {
 		*serverURL = spec.Servers[0].URL
+		// Store the default values of server variables, to allow changing them later.
+		for pair := orderedmap.First(spec.Servers[0].Variables); pair != nil; pair = pair.Next() {
+			name := pair.Key()
+			serverVariable := pair.Value()
+			value, ok := serverVariables[name]
+			if !ok {
+				serverVariables[name] = serverVariable.Default
+			} else if len(serverVariable.Enum) > 0 && !slices.Contains(serverVariable.Enum, value) {
+				exitWithError(fmt.Errorf("invalid value %q for server variable %v, must be one of: %v", value, name, strings.Join(serverVariable.Enum, ", ")))
+			}
+		}
+	}
+	if _, err := broom.ExpandServerURL(*serverURL, serverVariables); err != nil {
+		exitWithError(err)
 	}
 	if *authType == "" {
 		*authType = specAuthType
 	}
+	// Tie the credentials to the detected security scheme, unless a different type was requested.
+	authScheme := ""
+	if *authType == specAuthType {
+		authScheme = specAuthScheme
+	}
 	if *apiKeyHeader == "" {
 		*apiKeyHeader = specAPIKeyHeader
 	}
+	if *apiKeyCookie == "" {
+		*apiKeyCookie = specAPIKeyCookie
+	}
+	if *authType == "api-key-cookie" && *apiKeyCookie == "" {
+		exitWithError(fmt.Errorf("the api-key-cookie auth type requires --api-key-cookie"))
+	}
+	if *apiKeyQuery == "" {
+		*apiKeyQuery = specAPIKeyQuery
+	}
+	if *authType == "api-key-query" && *apiKeyQuery == "" {
+		exitWithError(fmt.Errorf("the api-key-query auth type requires --api-key-query"))
+	}
+	if *authType == "oauth2-client-credentials" || *authType == "oauth2-auth-code" {
+		if *clientID == "" {
+			exitWithError(fmt.Errorf("the %v auth type requires --client-id", *authType))
+		}
+		if *tokenURL == "" {
+			*tokenURL = specTokenURL
+		}
+		if *tokenURL == "" {
+			exitWithError(fmt.Errorf("the %v auth type requires --token-url", *authType))
+		}
+	}
+	if *authType == "oauth2-auth-code" {
+		if *authorizationURL == "" {
+			*authorizationURL = specAuthorizationURL
+		}
+		if *authorizationURL == "" {
+			exitWithError(fmt.Errorf("the oauth2-auth-code auth type requires --authorization-url"))
+		}
+	}
 	profileCfg := broom.ProfileConfig{}
 	profileCfg.SpecFile = filename
 	profileCfg.ServerURL = *serverURL
+	if len(serverVariables) > 0 {
+		profileCfg.ServerVariables = serverVariables
+	}
 	profileCfg.Auth = broom.AuthConfig{
-		Credentials:  *authCredentials,
-		Command:      *authCommand,
-		Type:         *authType,
-		APIKeyHeader: *apiKeyHeader,
+		Credentials:      *authCredentials,
+		Command:          *authCommand,
+		Type:             *authType,
+		APIKeyHeader:     *apiKeyHeader,
+		APIKeyCookie:     *apiKeyCookie,
+		APIKeyQuery:      *apiKeyQuery,
+		TokenURL:         *tokenURL,
+		ClientID:         *clientID,
+		Scopes:           *scopes,
+		Audience:         *audience,
+		AuthorizationURL: *authorizationURL,
+		RedirectURL:      *redirectURL,
+		Scheme:           authScheme,
 	}
 
 	// It is okay if the config file doesn't exist yet, so the error is ignored.
@@ -105,19 +206,35 @@ func addUsage() {
 	fmt.Fprintln(color.Output, "")
 	fmt.Fprintln(color.Output, "Adds a profile to the .broom.yaml config file in the current directory.")
 	fmt.Fprintln(color.Output, "")
-	fmt.Fprintln(color.Output, "The auth type, API key header, and server url will be auto-detected from")
-	fmt.Fprintln(color.Output, "the specification, unless they are provided via options.")
+	fmt.Fprintln(color.Output, "The spec file can be a path on disk or a URL. Remote specs are cached locally")
+	fmt.Fprintln(color.Output, "and revalidated on each use, with the cached copy used when offline.")
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "The auth type, API key location, OAuth2 token URL, and server url will be")
+	fmt.Fprintln(color.Output, "auto-detected from the specification, unless they are provided via options.")
+	fmt.Fprintln(color.Output, "Server variables default to the values defined in the specification.")
 	fmt.Fprintln(color.Output, "")
 	fmt.Fprintln(color.Output, color.YellowString("Examples:"))
 	fmt.Fprintln(color.Output, "   ", color.BlueString("Single profile"))
 	fmt.Fprintln(color.Output, `        broom add api openapi.yaml`)
 	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "   ", color.BlueString("Single profile with a remote spec"))
+	fmt.Fprintln(color.Output, `        broom add api https://my-api.io/openapi.yaml`)
+	fmt.Fprintln(color.Output, "")
 	fmt.Fprintln(color.Output, "   ", color.BlueString("Single profile with Basic auth"))
 	fmt.Fprintln(color.Output, `        broom add api openapi.yaml --auth="myuser:mypass" --auth-type=basic`)
 	fmt.Fprintln(color.Output, "")
 	fmt.Fprintln(color.Output, "   ", color.BlueString("Single profile with Bearer auth via external command"))
 	fmt.Fprintln(color.Output, `        broom add api openapi.json --auth-cmd="sh get-token.sh" --auth-type=bearer`)
 	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "   ", color.BlueString("Single profile with OAuth2 client credentials"))
+	fmt.Fprintln(color.Output, `        broom add api openapi.yaml --auth=CLIENT_SECRET --auth-type=oauth2-client-credentials --client-id=CLIENT_ID --scopes=read,write`)
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "   ", color.BlueString("Single profile with an OAuth2 user login"))
+	fmt.Fprintln(color.Output, `        broom add api openapi.yaml --auth-type=oauth2-auth-code --client-id=CLIENT_ID --scopes=openid,read`)
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "   ", color.BlueString("Single profile with a server variable"))
+	fmt.Fprintln(color.Output, `        broom add api openapi.yaml --server-var region=eu`)
+	fmt.Fprintln(color.Output, "")
 	fmt.Fprintln(color.Output, "   ", color.BlueString("Multiple profiles with different API keys"))
 	fmt.Fprintln(color.Output, `        broom add prod openapi.yaml --auth=PRODUCTION_KEY --auth-type=api-key`)
 	fmt.Fprintln(color.Output, `        broom add staging openapi.yaml --auth=STAGING_KEY --auth-type=api-key --server-url=htts://staging.my-api.io`)
@@ -0,0 +1,93 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	flag "github.com/spf13/pflag"
+
+	"github.com/bojanz/broom"
+)
+
+const diffDescription = `Compare two versions of a spec`
+
+func diffCmd(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	var (
+		help         = flags.BoolP("help", "h", false, "Display this help text and exit")
+		breakingOnly = flags.Bool("breaking", false, "Only list breaking changes")
+	)
+	flags.SortFlags = false
+	if err := flags.Parse(args); err != nil {
+		exitWithError(err)
+	}
+	if *help || flags.NArg() < 3 {
+		diffUsage()
+		flagUsage(flags)
+		return
+	}
+
+	oldFilename := flags.Arg(1)
+	newFilename := flags.Arg(2)
+	// The old spec can also be specified via a profile.
+	if _, err := os.Stat(oldFilename); err != nil && !broom.IsURL(oldFilename) {
+		cfg, cfgErr := broom.ReadConfig(".broom.yaml")
+		if cfgErr != nil && !errors.Is(cfgErr, os.ErrNotExist) {
+			exitWithError(cfgErr)
+		}
+		if profileCfg, ok := cfg[oldFilename]; ok {
+			oldFilename = profileCfg.SpecFile
+		}
+	}
+	oldOps, err := broom.LoadOperations(oldFilename)
+	if err != nil {
+		exitWithError(err)
+	}
+	newOps, err := broom.LoadOperations(newFilename)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	numBreaking := 0
+	numChanges := 0
+	for _, change := range broom.DiffOperations(oldOps, newOps) {
+		if change.Breaking {
+			fmt.Fprintln(color.Output, color.RedString("breaking:"), change)
+			numBreaking++
+		} else if !*breakingOnly {
+			fmt.Fprintln(color.Output, color.GreenString("non-breaking:"), change)
+		}
+		numChanges++
+	}
+	if numBreaking > 0 {
+		fmt.Fprintf(color.Error, "%v Found %v breaking change(s)\n", color.RedString("Error:"), numBreaking)
+		os.Exit(1)
+	}
+	if numChanges == 0 {
+		fmt.Fprintln(color.Output, "No changes found")
+	}
+}
+
+func diffUsage() {
+	fmt.Fprintln(color.Output, color.YellowString("Usage:"), "broom diff", color.GreenString("<old_spec>"), color.GreenString("<new_spec>"))
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "Compares the operations of two versions of a spec, on disk or at a remote URL.")
+	fmt.Fprintln(color.Output, "The old spec can also be given as a profile name, to use the profile's current spec.")
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "Lists added and removed operations, and changed parameters and content types.")
+	fmt.Fprintln(color.Output, "Exits with a non-zero status if any breaking changes were found.")
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, color.YellowString("Examples:"))
+	fmt.Fprintln(color.Output, "   ", color.BlueString("Two spec files"))
+	fmt.Fprintln(color.Output, `        broom diff openapi.v1.yaml openapi.v2.yaml`)
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "   ", color.BlueString("The api profile's spec and a new version"))
+	fmt.Fprintln(color.Output, `        broom diff api https://my-api.io/openapi.yaml`)
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, color.YellowString("Options:"))
+}
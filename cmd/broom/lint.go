@@ -0,0 +1,70 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	flag "github.com/spf13/pflag"
+
+	"github.com/bojanz/broom"
+)
+
+const lintDescription = `Check a profile and its spec for problems`
+
+func lintCmd(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	help := flags.BoolP("help", "h", false, "Display this help text and exit")
+	flags.SortFlags = false
+	if err := flags.Parse(args); err != nil {
+		exitWithError(err)
+	}
+	if *help || flags.NArg() < 2 {
+		lintUsage()
+		flagUsage(flags)
+		return
+	}
+
+	profile := flags.Arg(1)
+	cfg, err := broom.ReadConfig(".broom.yaml")
+	if err != nil {
+		exitWithError(err)
+	}
+	profileCfg, ok := cfg[profile]
+	if !ok {
+		exitWithError(fmt.Errorf("unknown profile %v", profile))
+	}
+	specIssues, err := broom.Lint(profileCfg.SpecFile)
+	if err != nil {
+		exitWithError(err)
+	}
+	profileIssues := broom.LintProfile(profile, profileCfg)
+
+	for _, issue := range profileIssues {
+		fmt.Fprintln(color.Output, color.YellowString(".broom.yaml:"), issue)
+	}
+	for _, issue := range specIssues {
+		fmt.Fprintln(color.Output, color.YellowString(profileCfg.SpecFile+":"), issue)
+	}
+	numIssues := len(profileIssues) + len(specIssues)
+	if numIssues > 0 {
+		fmt.Fprintf(color.Error, "%v Found %v issue(s)\n", color.RedString("Error:"), numIssues)
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Output, "No issues found in the %v profile\n", profile)
+}
+
+func lintUsage() {
+	fmt.Fprintln(color.Output, color.YellowString("Usage:"), "broom lint", color.GreenString("<profile>"))
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "Checks a profile and its spec for problems that prevent Broom from using them:")
+	fmt.Fprintln(color.Output, "duplicate operation IDs, schemas without types, unresolvable references,")
+	fmt.Fprintln(color.Output, "undeclared path parameters, unsupported body formats and auth schemes.")
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, "Exits with a non-zero status if any issues were found.")
+	fmt.Fprintln(color.Output, "")
+	fmt.Fprintln(color.Output, color.YellowString("Options:"))
+}
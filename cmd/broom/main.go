@@ -37,6 +37,8 @@ func main() {
 		fmt.Fprintln(w, color.YellowString("Commands:"))
 		fmt.Fprintf(w, "\t%v\t%v\n", color.GreenString("<profile>"), profileDescription)
 		fmt.Fprintf(w, "\t%v\t%v\n", color.GreenString("add"), addDescription)
+		fmt.Fprintf(w, "\t%v\t%v\n", color.GreenString("diff"), diffDescription)
+		fmt.Fprintf(w, "\t%v\t%v\n", color.GreenString("lint"), lintDescription)
 		fmt.Fprintf(w, "\t%v\t%v\n", color.GreenString("rm"), rmDescription)
 		fmt.Fprintf(w, "\t%v\t%v\n\n", color.GreenString("version"), versionDescription)
 		if len(profiles) > 0 {
@@ -55,6 +57,10 @@ func main() {
 	switch command {
 	case "add":
 		addCmd(args)
+	case "diff":
+		diffCmd(args)
+	case "lint":
+		lintCmd(args)
 	case "rm":
 		rmCmd(args)
 	case "version":
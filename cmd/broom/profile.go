@@ -4,9 +4,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"runtime"
+	"slices"
 	"strings"
 	"text/tabwriter"
 
@@ -22,11 +29,19 @@ const profileDescription = `List the profile's operations`
 func profileCmd(args []string) {
 	flags := flag.NewFlagSet("profile", flag.ContinueOnError)
 	var (
-		help    = flags.BoolP("help", "h", false, "Display this help text and exit")
-		headers = flags.StringArrayP("header", "H", nil, "Header. Can be used multiple times")
-		body    = flags.StringP("body", "b", "", "Body string, containing one or more body parameters")
-		query   = flags.StringP("query", "q", "", "Query string, containing one or more query parameters")
-		verbose = flags.BoolP("verbose", "v", false, "Print the HTTP status and headers hefore the response body")
+		help       = flags.BoolP("help", "h", false, "Display this help text and exit")
+		headers    = flags.StringArrayP("header", "H", nil, "Header. Can be used multiple times")
+		cookies    = flags.StringArrayP("cookie", "c", nil, "Cookie, in the name=value format. Can be used multiple times")
+		body       = flags.StringP("body", "b", "", "Body string, containing one or more body parameters")
+		bodyFile   = flags.String("body-file", "", "Body file, sent as-is, or with the body string merged on top. Use - for stdin")
+		query      = flags.StringP("query", "q", "", "Query string, containing one or more query parameters")
+		format     = flags.String("content-type", "", "Body content type, for operations accepting multiple. Defaults to JSON when available")
+		variant    = flags.String("variant", "", "Body variant, for operations accepting multiple body schemas")
+		serverVars = flags.StringArray("server-var", nil, "Server variable, in the name=value format. Can be used multiple times")
+		verbose    = flags.BoolP("verbose", "v", false, "Print the HTTP status and headers hefore the response body")
+		noCache    = flags.Bool("no-cache", false, "Parse the spec instead of using the cached operations")
+		noValidate = flags.Bool("no-validate", false, "Send the request without validating the parameter values, or checking for required ones")
+		forceLogin = flags.Bool("login", false, "Log in again, even if already logged in. Used by the oauth2-auth-code auth type")
 	)
 	flags.SortFlags = false
 	if err := flags.Parse(args); err != nil {
@@ -42,13 +57,38 @@ func profileCmd(args []string) {
 	if !ok {
 		exitWithError(fmt.Errorf("unknown profile %v", profile))
 	}
-	ops, err := broom.LoadOperations(profileCfg.SpecFile)
+	var contents broom.SpecContents
+	if *noCache {
+		contents, err = broom.LoadSpecContents(profileCfg.SpecFile)
+	} else {
+		contents, err = broom.LoadCachedSpecContents(profileCfg.SpecFile)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	ops := contents.Operations
+	serverVariables := make(map[string]string, len(profileCfg.ServerVariables))
+	maps.Copy(serverVariables, profileCfg.ServerVariables)
+	flagServerVariables, err := broom.ParseServerVariables(*serverVars)
 	if err != nil {
 		exitWithError(err)
 	}
+	maps.Copy(serverVariables, flagServerVariables)
+	// Values stored in the profile are checked too, the spec might have changed since.
+	for _, server := range contents.Servers {
+		if server.URL == profileCfg.ServerURL {
+			if err := server.ValidateVariables(serverVariables); err != nil {
+				exitWithError(err)
+			}
+		}
+	}
 	// No operation specified, list all of them.
 	if flags.NArg() < 2 {
-		profileUsage(profile, profileCfg.ServerURL, ops)
+		serverURL, err := broom.ExpandServerURL(profileCfg.ServerURL, serverVariables)
+		if err != nil {
+			serverURL = profileCfg.ServerURL
+		}
+		profileUsage(profile, serverURL, ops, contents.Tags)
 		return
 	}
 
@@ -57,9 +97,23 @@ func profileCmd(args []string) {
 	if !ok {
 		exitWithError(fmt.Errorf("unknown operation %s", opID))
 	}
+	if *format != "" {
+		op, err = op.WithBodyFormat(*format)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+	if *variant != "" {
+		op, err = op.WithBodyVariant(*variant)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
 	pathValues := flags.Args()[2:]
 	if *help || len(op.Parameters.Path) > len(pathValues) {
-		operationUsage(op, profile)
+		// A missing auth config is reported once the request is sent.
+		authCfgs, _ := profileCfg.AuthFor(op)
+		operationUsage(op, profile, authCfgs)
 		flagUsage(flags)
 		return
 	}
@@ -67,16 +121,72 @@ func profileCmd(args []string) {
 	if err != nil {
 		exitWithError(err)
 	}
+	values.Cookie, err = broom.ParseCookieValues(*cookies)
+	if err != nil {
+		exitWithError(err)
+	}
+	if *bodyFile != "" {
+		values.RawBody, err = readBodyFile(*bodyFile)
+		if err != nil {
+			exitWithError(fmt.Errorf("read body file: %w", err))
+		}
+	}
+	// Identify the selected variant to the server, unless done by the user.
+	if v, ok := op.BodyVariant(*variant); ok && v.Discriminator != "" && !values.Body.Has(v.Discriminator) {
+		values.Body.Set(v.Discriminator, v.Name)
+	}
+
+	// Credentials are added after validation, don't require them.
+	authCfgs, err := profileCfg.AuthFor(op)
+	if err != nil {
+		// The credentials can still be passed via -H.
+		fmt.Fprintf(color.Error, "%v %v, sending the request without them\n", color.YellowString("Warning:"), err)
+	}
+	op.Parameters = withoutAuthParameters(op.Parameters, authCfgs)
+	if !*noValidate {
+		if err := op.Validate(values); err != nil {
+			exitWithError(err)
+		}
+		if err := op.ValidateValues(values); err != nil {
+			exitWithError(err)
+		}
+	}
 
-	req, err := op.Request(profileCfg.ServerURL, values)
+	serverURL, err := op.ServerURL(profileCfg.ServerURL, serverVariables)
 	if err != nil {
 		exitWithError(err)
 	}
-	if err = broom.Authenticate(req, profileCfg.Auth); err != nil {
-		exitWithError(fmt.Errorf("authenticate: %w", err))
+	req, err := op.Request(serverURL, values)
+	if err != nil {
+		exitWithError(err)
+	}
+	for _, authCfg := range authCfgs {
+		if *forceLogin && authCfg.Type == "oauth2-auth-code" {
+			if err = login(authCfg); err != nil {
+				exitWithError(fmt.Errorf("login: %w", err))
+			}
+		}
+		err = broom.Authenticate(req, authCfg)
+		if errors.Is(err, broom.ErrLoginRequired) {
+			if err = login(authCfg); err != nil {
+				exitWithError(fmt.Errorf("login: %w", err))
+			}
+			err = broom.Authenticate(req, authCfg)
+		}
+		if err != nil {
+			exitWithError(fmt.Errorf("authenticate: %w", err))
+		}
+	}
+	if *verbose {
+		fmt.Fprintln(color.Output, req.Method, broom.RedactURL(req.URL, authCfgs...))
 	}
 	result, err := broom.Execute(req, *verbose)
 	if err != nil {
+		// Transport errors include the request URL, which might contain credentials.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = broom.RedactURL(req.URL, authCfgs...)
+		}
 		exitWithError(err)
 	}
 
@@ -86,8 +196,57 @@ func profileCmd(args []string) {
 	}
 }
 
+// withoutAuthParameters removes the parameters populated by the given auth configs.
+func withoutAuthParameters(params broom.Parameters, authCfgs []broom.AuthConfig) broom.Parameters {
+	isAuthParam := func(p broom.Parameter) bool {
+		return slices.ContainsFunc(authCfgs, func(authCfg broom.AuthConfig) bool {
+			return authCfg.ProvidesParameter(p)
+		})
+	}
+	params.Header = slices.DeleteFunc(slices.Clone(params.Header), isAuthParam)
+	params.Cookie = slices.DeleteFunc(slices.Clone(params.Cookie), isAuthParam)
+	params.Query = slices.DeleteFunc(slices.Clone(params.Query), isAuthParam)
+
+	return params
+}
+
+// login logs in using the given auth config, opening the authorization URL in the browser.
+func login(authCfg broom.AuthConfig) error {
+	return broom.Login(authCfg, func(authURL string) {
+		fmt.Fprintln(color.Error, "Opening the browser to log in. If it doesn't open, visit:")
+		fmt.Fprintln(color.Error, authURL)
+		openBrowser(authURL)
+	})
+}
+
+// openBrowser opens the given URL in the user's browser.
+//
+// Failures are ignored, since the URL is also printed.
+func openBrowser(authURL string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", authURL)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", authURL)
+	default:
+		cmd = exec.Command("xdg-open", authURL)
+	}
+	if err := cmd.Start(); err == nil {
+		go cmd.Wait()
+	}
+}
+
+// readBodyFile reads the body file with the given name, or stdin if the name is "-".
+func readBodyFile(filename string) ([]byte, error) {
+	if filename == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filename)
+}
+
 // profileUsage prints Broom usage for a single profile.
-func profileUsage(profile string, serverURL string, ops broom.Operations) {
+func profileUsage(profile string, serverURL string, ops broom.Operations, tags []broom.Tag) {
 	fmt.Fprintln(color.Output, color.YellowString("Usage:"), "broom", profile, color.GreenString("<operation>"), "[--help]")
 	fmt.Fprintln(color.Output, "")
 	fmt.Fprintln(color.Output, "Runs the specified operation on", serverURL)
@@ -95,9 +254,11 @@ func profileUsage(profile string, serverURL string, ops broom.Operations) {
 		fmt.Fprintln(color.Output, "")
 		fmt.Fprintln(color.Output, color.YellowString("Operations:"))
 		w := tabwriter.NewWriter(color.Output, 0, 1, 4, ' ', 0)
-		for _, tag := range ops.Tags() {
-			fmt.Fprintf(w, "\t%v\t\t\n", color.BlueString(tag))
-			for _, op := range ops.ByTag(tag) {
+		for _, tag := range ops.Tags(tags) {
+			// Only the first line of the description fits in the listing.
+			description, _, _ := strings.Cut(tag.Description, "\n")
+			fmt.Fprintf(w, "\t%v\t%v\n", color.BlueString(tag.Name), description)
+			for _, op := range ops.ByTag(tag.Name) {
 				fmt.Fprintf(w, "\t    %v\t%v\n", color.GreenString(op.ID), op.SummaryWithFlags())
 			}
 		}
@@ -108,7 +269,9 @@ func profileUsage(profile string, serverURL string, ops broom.Operations) {
 }
 
 // operationUsage prints Broom usage for a single operation.
-func operationUsage(op broom.Operation, profile string) {
+//
+// The given auth configs are the ones selected for the operation.
+func operationUsage(op broom.Operation, profile string, authCfgs []broom.AuthConfig) {
 	sb := strings.Builder{}
 	sb.WriteString(op.ID)
 	for _, param := range op.Parameters.Path {
@@ -124,6 +287,23 @@ func operationUsage(op broom.Operation, profile string) {
 		fmt.Fprintln(color.Output, "")
 		fmt.Fprintln(color.Output, op.Description)
 	}
+	if len(op.Security) > 0 {
+		fmt.Fprintln(color.Output, "")
+		fmt.Fprintln(color.Output, color.YellowString("Authentication:"))
+		w := tabwriter.NewWriter(color.Output, 0, 1, 4, ' ', 0)
+		for _, req := range op.Security {
+			schemes := "none"
+			if len(req) > 0 {
+				schemes = strings.Join(req, " + ")
+			}
+			selected := ""
+			if slices.Equal(req, authSchemes(authCfgs)) {
+				selected = "(selected)"
+			}
+			fmt.Fprintf(w, "\t%v\t%v\n", color.GreenString(schemes), selected)
+		}
+		w.Flush()
+	}
 	if len(op.Parameters.Header) > 0 {
 		fmt.Fprintln(color.Output, "")
 		fmt.Fprintln(color.Output, color.YellowString("Header parameters:"))
@@ -134,6 +314,16 @@ func operationUsage(op broom.Operation, profile string) {
 		}
 		w.Flush()
 	}
+	if len(op.Parameters.Cookie) > 0 {
+		fmt.Fprintln(color.Output, "")
+		fmt.Fprintln(color.Output, color.YellowString("Cookie parameters:"))
+		w := tabwriter.NewWriter(color.Output, 0, 1, 4, ' ', 0)
+		for _, param := range op.Parameters.Cookie {
+			description := prepareParameterDescription(param)
+			fmt.Fprintf(w, "\t%v %v\t%v\n", color.GreenString(param.Name), param.FormattedFlags(), description)
+		}
+		w.Flush()
+	}
 	if len(op.Parameters.Query) > 0 {
 		fmt.Fprintln(color.Output, "")
 		fmt.Fprintln(color.Output, color.YellowString("Query parameters:"))
@@ -154,10 +344,53 @@ func operationUsage(op broom.Operation, profile string) {
 		}
 		w.Flush()
 	}
+	if len(op.Bodies) > 1 {
+		fmt.Fprintln(color.Output, "")
+		fmt.Fprintln(color.Output, color.YellowString("Content types:"))
+		w := tabwriter.NewWriter(color.Output, 0, 1, 4, ' ', 0)
+		for _, body := range op.Bodies {
+			selected := ""
+			if body.Format == op.BodyFormat {
+				selected = "(selected)"
+			}
+			fmt.Fprintf(w, "\t%v\t%v\n", color.GreenString(body.Format), selected)
+		}
+		w.Flush()
+	}
+	if len(op.BodyVariants) > 0 {
+		fmt.Fprintln(color.Output, "")
+		fmt.Fprintln(color.Output, color.YellowString("Body variants:"))
+		w := tabwriter.NewWriter(color.Output, 0, 1, 4, ' ', 0)
+		for _, v := range op.BodyVariants {
+			fmt.Fprintf(w, "\t%v\t%v\n", color.GreenString(v.Name), v.Description)
+		}
+		w.Flush()
+		fmt.Fprintln(color.Output, "")
+		fmt.Fprintf(color.Output, "Run 'broom %v %v --variant=%v --help' to view the parameters of a variant.\n", profile, op.ID, color.GreenString("<variant>"))
+	}
+	if len(op.Responses) > 0 {
+		fmt.Fprintln(color.Output, "")
+		fmt.Fprintln(color.Output, color.YellowString("Responses:"))
+		w := tabwriter.NewWriter(color.Output, 0, 1, 4, ' ', 0)
+		for _, resp := range op.Responses {
+			description := prepareResponseDescription(resp)
+			fmt.Fprintf(w, "\t%v\t%v\n", color.GreenString(resp.StatusCode), description)
+		}
+		w.Flush()
+	}
 	fmt.Fprintln(color.Output, "")
 	fmt.Fprintln(color.Output, color.YellowString("Options:"))
 }
 
+// authSchemes returns the security scheme names of the given auth configs.
+func authSchemes(authCfgs []broom.AuthConfig) broom.SecurityRequirement {
+	schemes := make(broom.SecurityRequirement, 0, len(authCfgs))
+	for _, authCfg := range authCfgs {
+		schemes = append(schemes, authCfg.Scheme)
+	}
+	return schemes
+}
+
 // prepareParameterDescription prepares a parameter description for display.
 //
 // Adds default and example values.
@@ -181,3 +414,27 @@ func prepareParameterDescription(p broom.Parameter) string {
 
 	return description
 }
+
+// prepareResponseDescription prepares a response description for display.
+//
+// Adds the content types, a summary of the schema, and the example, if any.
+// If a description has multiple lines, all lines are indented to match the first line's width.
+func prepareResponseDescription(resp broom.Response) string {
+	lines := []string{resp.Description}
+	if len(resp.Formats) > 0 {
+		schema := resp.Type
+		if len(resp.Fields) > 0 {
+			schema = fmt.Sprintf("%v {%v}", schema, strings.Join(resp.Fields, ", "))
+		}
+		lines = append(lines, fmt.Sprintf("%v %v", color.YellowString(strings.Join(resp.Formats, ", ")+":"), schema))
+	}
+	if resp.Example != "" {
+		lines = append(lines, fmt.Sprintf("%v %v", color.YellowString("Example:"), resp.Example))
+	}
+	description := strings.Join(lines, "\n")
+	// Since colors are used for the name column, tabwriter requires color codes to
+	// be present even when that column is empty, for the tab width to be right.
+	description = strings.ReplaceAll(description, "\n", "\n\t"+color.GreenString("")+"\t")
+
+	return description
+}
@@ -6,7 +6,9 @@ package broom
 import (
 	"fmt"
 	"os"
+	"slices"
 	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
@@ -27,9 +29,56 @@ func (c Config) Profiles() []string {
 
 // ProfileConfig represents Broom's per-profile configuration.
 type ProfileConfig struct {
-	SpecFile  string     `yaml:"spec_file"`
-	ServerURL string     `yaml:"server_url"`
-	Auth      AuthConfig `yaml:"auth"`
+	SpecFile        string            `yaml:"spec_file"`
+	ServerURL       string            `yaml:"server_url"`
+	ServerVariables map[string]string `yaml:"server_variables,omitempty"`
+	Auth            AuthConfig        `yaml:"auth"`
+	// AdditionalAuth contains the credentials for other security schemes,
+	// used by operations which can't be authenticated using Auth.
+	AdditionalAuth []AuthConfig `yaml:"additional_auth,omitempty"`
+}
+
+// AuthFor returns the auth configs to use for the given operation.
+//
+// The operation's security requirements are checked in order, picking the
+// first one for which each security scheme has a matching auth config.
+// If none match, the main auth config is used when it doesn't name a scheme,
+// as it is for specs which don't define security requirements. Otherwise,
+// operations allowing anonymous access get no auth configs, and the rest
+// get an error, since credentials for a different scheme can't be used.
+func (cfg ProfileConfig) AuthFor(op Operation) ([]AuthConfig, error) {
+	if op.Security == nil {
+		return []AuthConfig{cfg.Auth}, nil
+	}
+	authCfgs := append([]AuthConfig{cfg.Auth}, cfg.AdditionalAuth...)
+	requirements := make([]string, 0, len(op.Security))
+	for _, req := range op.Security {
+		if len(req) == 0 {
+			continue
+		}
+		requirements = append(requirements, strings.Join(req, " + "))
+		matched := make([]AuthConfig, 0, len(req))
+		for _, scheme := range req {
+			i := slices.IndexFunc(authCfgs, func(authCfg AuthConfig) bool {
+				return authCfg.Scheme == scheme
+			})
+			if i == -1 {
+				break
+			}
+			matched = append(matched, authCfgs[i])
+		}
+		if len(matched) == len(req) {
+			return matched, nil
+		}
+	}
+	if len(requirements) > 0 && cfg.Auth.Scheme == "" {
+		return []AuthConfig{cfg.Auth}, nil
+	}
+	if op.IsPublic() {
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("no credentials for security schemes %v", strings.Join(requirements, " or "))
 }
 
 // AuthConfig represents a profile's authentication configuration.
@@ -38,6 +87,23 @@ type AuthConfig struct {
 	Command      string `yaml:"command"`
 	Type         string `yaml:"type"`
 	APIKeyHeader string `yaml:"api_key_header"`
+	APIKeyCookie string `yaml:"api_key_cookie,omitempty"`
+	APIKeyQuery  string `yaml:"api_key_query,omitempty"`
+	// TokenURL, ClientID, Scopes, and Audience are used by the OAuth2 auth types
+	// (oauth2-client-credentials, oauth2-auth-code), which use the credentials
+	// as the client secret.
+	TokenURL string   `yaml:"token_url,omitempty"`
+	ClientID string   `yaml:"client_id,omitempty"`
+	Scopes   []string `yaml:"scopes,omitempty"`
+	Audience string   `yaml:"audience,omitempty"`
+	// AuthorizationURL and RedirectURL are used by the oauth2-auth-code auth type.
+	AuthorizationURL string `yaml:"authorization_url,omitempty"`
+	RedirectURL      string `yaml:"redirect_url,omitempty"`
+	// Scheme is the name of the spec's security scheme that the credentials are for.
+	Scheme string `yaml:"scheme,omitempty"`
+	// Profile is the name of the profile the auth config belongs to.
+	// Set by ReadConfig, used to store the tokens obtained via Login.
+	Profile string `yaml:"-"`
 }
 
 // ReadConfig reads a config file with the given filename.
@@ -54,6 +120,13 @@ func ReadConfig(filename string) (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	for profile, profileCfg := range config {
+		profileCfg.Auth.Profile = profile
+		for i := range profileCfg.AdditionalAuth {
+			profileCfg.AdditionalAuth[i].Profile = profile
+		}
+		config[profile] = profileCfg
+	}
 
 	return config, nil
 }
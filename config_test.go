@@ -0,0 +1,81 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom_test
+
+import (
+	"testing"
+
+	"github.com/bojanz/broom"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProfileConfig_AuthFor(t *testing.T) {
+	bearer := broom.AuthConfig{Type: "bearer", Credentials: "MYTOKEN", Scheme: "BearerAuth"}
+	apiKey := broom.AuthConfig{Type: "api-key", Credentials: "MYKEY", APIKeyHeader: "X-API-Key", Scheme: "ApiKey"}
+	storeID := broom.AuthConfig{Type: "api-key", Credentials: "MYSTORE", APIKeyHeader: "X-Store-ID", Scheme: "StoreId"}
+	profileCfg := broom.ProfileConfig{
+		Auth:           bearer,
+		AdditionalAuth: []broom.AuthConfig{apiKey, storeID},
+	}
+	tests := []struct {
+		name     string
+		security []broom.SecurityRequirement
+		want     []broom.AuthConfig
+		wantErr  string
+	}{
+		{"no security", nil, []broom.AuthConfig{bearer}, ""},
+		{"public", []broom.SecurityRequirement{{}}, nil, ""},
+		{"optional", []broom.SecurityRequirement{{}, {"BearerAuth"}}, []broom.AuthConfig{bearer}, ""},
+		{"optional no match", []broom.SecurityRequirement{{}, {"OAuth"}}, nil, ""},
+		{"additional", []broom.SecurityRequirement{{"ApiKey", "StoreId"}, {"BearerAuth"}}, []broom.AuthConfig{apiKey, storeID}, ""},
+		{"partial match", []broom.SecurityRequirement{{"ApiKey", "OAuth"}, {"BearerAuth"}}, []broom.AuthConfig{bearer}, ""},
+		{"no match", []broom.SecurityRequirement{{"ApiKey", "OAuth"}, {"OAuth"}}, nil, "no credentials for security schemes ApiKey + OAuth or OAuth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := broom.Operation{Security: tt.security}
+			got, err := profileCfg.AuthFor(op)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("auth mismatch (-want +got):\n%s", diff)
+			}
+			gotErr := ""
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("got %q, want %q", gotErr, tt.wantErr)
+			}
+		})
+	}
+
+	// Without a scheme, the main auth config is used when no other one matches.
+	bearer.Scheme = ""
+	profileCfg = broom.ProfileConfig{
+		Auth:           bearer,
+		AdditionalAuth: []broom.AuthConfig{apiKey},
+	}
+	tests = []struct {
+		name     string
+		security []broom.SecurityRequirement
+		want     []broom.AuthConfig
+		wantErr  string
+	}{
+		{"public", []broom.SecurityRequirement{{}}, nil, ""},
+		{"optional", []broom.SecurityRequirement{{}, {"BearerAuth"}}, []broom.AuthConfig{bearer}, ""},
+		{"additional", []broom.SecurityRequirement{{"ApiKey"}, {"BearerAuth"}}, []broom.AuthConfig{apiKey}, ""},
+		{"no match", []broom.SecurityRequirement{{"OAuth"}}, []broom.AuthConfig{bearer}, ""},
+	}
+	for _, tt := range tests {
+		t.Run("unnamed "+tt.name, func(t *testing.T) {
+			op := broom.Operation{Security: tt.security}
+			got, err := profileCfg.AuthFor(op)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("auth mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Change represents a difference between two versions of an operation.
+type Change struct {
+	OperationID string
+	Message     string
+	// Breaking indicates whether the change can break existing callers.
+	Breaking bool
+}
+
+// String returns the string representation of the change.
+func (c Change) String() string {
+	return c.OperationID + ": " + c.Message
+}
+
+// DiffOperations compares two versions of a specification's operations.
+//
+// Operations are matched by ID. Removed and changed operations are listed
+// first, in the old order, followed by the added operations, in the new order.
+func DiffOperations(oldOps Operations, newOps Operations) []Change {
+	var changes []Change
+	for _, oldOp := range oldOps {
+		newOp, ok := newOps.ByID(oldOp.ID)
+		if !ok {
+			changes = append(changes, Change{OperationID: oldOp.ID, Message: "operation removed", Breaking: true})
+			continue
+		}
+		changes = append(changes, diffOperation(oldOp, newOp)...)
+	}
+	for _, newOp := range newOps {
+		if _, ok := oldOps.ByID(newOp.ID); !ok {
+			changes = append(changes, Change{OperationID: newOp.ID, Message: "operation added"})
+		}
+	}
+
+	return changes
+}
+
+// diffOperation compares two versions of the same operation.
+func diffOperation(oldOp Operation, newOp Operation) []Change {
+	var changes []Change
+	addChange := func(breaking bool, format string, a ...any) {
+		changes = append(changes, Change{OperationID: newOp.ID, Message: fmt.Sprintf(format, a...), Breaking: breaking})
+	}
+	if oldOp.Method != newOp.Method || oldOp.Path != newOp.Path {
+		addChange(true, "changed from %v %v to %v %v", oldOp.Method, oldOp.Path, newOp.Method, newOp.Path)
+	}
+	if !oldOp.Deprecated && newOp.Deprecated {
+		addChange(false, "operation deprecated")
+	}
+
+	paramLists := []struct {
+		in      string
+		oldList ParameterList
+		newList ParameterList
+	}{
+		{"header", oldOp.Parameters.Header, newOp.Parameters.Header},
+		{"cookie", oldOp.Parameters.Cookie, newOp.Parameters.Cookie},
+		{"path", oldOp.Parameters.Path, newOp.Parameters.Path},
+		{"query", oldOp.Parameters.Query, newOp.Parameters.Query},
+		{"body", oldOp.Parameters.Body, newOp.Parameters.Body},
+	}
+	for _, pl := range paramLists {
+		for _, oldParam := range pl.oldList {
+			newParam, ok := pl.newList.ByName(oldParam.Name)
+			if !ok {
+				addChange(true, "removed %v parameter %q", pl.in, oldParam.Name)
+				continue
+			}
+			if oldParam.Type != newParam.Type {
+				addChange(true, "%v parameter %q changed type from %v to %v", pl.in, newParam.Name, formatType(oldParam.Type), formatType(newParam.Type))
+			}
+			if !oldParam.Required && newParam.Required {
+				addChange(true, "%v parameter %q is now required", pl.in, newParam.Name)
+			} else if oldParam.Required && !newParam.Required {
+				addChange(false, "%v parameter %q is no longer required", pl.in, newParam.Name)
+			}
+			if len(newParam.Enum) > 0 {
+				var removedValues []string
+				for _, v := range oldParam.Enum {
+					if !slices.Contains(newParam.Enum, v) {
+						removedValues = append(removedValues, v)
+					}
+				}
+				if len(oldParam.Enum) == 0 || len(removedValues) > 0 {
+					addChange(true, "%v parameter %q now only accepts: %v", pl.in, newParam.Name, strings.Join(newParam.Enum, ", "))
+				}
+			}
+		}
+		for _, newParam := range pl.newList {
+			if _, ok := pl.oldList.ByName(newParam.Name); ok {
+				continue
+			}
+			if newParam.Required {
+				addChange(true, "added required %v parameter %q", pl.in, newParam.Name)
+			} else {
+				addChange(false, "added %v parameter %q", pl.in, newParam.Name)
+			}
+		}
+	}
+
+	oldFormats := bodyFormats(oldOp)
+	newFormats := bodyFormats(newOp)
+	for _, format := range oldFormats {
+		if !slices.Contains(newFormats, format) {
+			addChange(true, "removed content type %v", format)
+		}
+	}
+	for _, format := range newFormats {
+		if !slices.Contains(oldFormats, format) {
+			addChange(false, "added content type %v", format)
+		}
+	}
+
+	return changes
+}
+
+// bodyFormats returns the body formats supported by the given operation.
+func bodyFormats(op Operation) []string {
+	formats := make([]string, 0, len(op.Bodies))
+	for _, body := range op.Bodies {
+		formats = append(formats, body.Format)
+	}
+
+	return formats
+}
+
+// formatType formats the given parameter type for display.
+func formatType(paramType string) string {
+	if paramType == "" {
+		return "untyped"
+	}
+
+	return paramType
+}
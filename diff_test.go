@@ -0,0 +1,112 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom_test
+
+import (
+	"testing"
+
+	"github.com/bojanz/broom"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffOperations(t *testing.T) {
+	oldOps := broom.Operations{
+		broom.Operation{
+			ID:     "list-products",
+			Method: "GET",
+			Path:   "/products",
+			Parameters: broom.Parameters{
+				Query: broom.ParameterList{
+					{In: "query", Name: "filter[owner_id]", Type: "string"},
+					{In: "query", Name: "sort", Type: "string", Enum: []string{"name", "price"}},
+					{In: "query", Name: "page", Type: "string"},
+				},
+			},
+		},
+		broom.Operation{
+			ID:     "create-product",
+			Method: "POST",
+			Path:   "/products",
+			Parameters: broom.Parameters{
+				Body: broom.ParameterList{
+					{In: "body", Name: "name", Type: "string", Required: true},
+					{In: "body", Name: "price", Type: "string"},
+				},
+			},
+			BodyFormat: "application/json",
+			Bodies: []broom.Body{
+				{Format: "application/json"},
+				{Format: "application/xml"},
+			},
+		},
+		broom.Operation{
+			ID:     "delete-product",
+			Method: "DELETE",
+			Path:   "/products/{product_id}",
+		},
+	}
+	newOps := broom.Operations{
+		broom.Operation{
+			ID:     "list-products",
+			Method: "GET",
+			Path:   "/products",
+			Parameters: broom.Parameters{
+				Query: broom.ParameterList{
+					{In: "query", Name: "filter[owner_id]", Type: "string"},
+					{In: "query", Name: "sort", Type: "string", Enum: []string{"name"}},
+					{In: "query", Name: "page", Type: "integer"},
+					{In: "query", Name: "limit", Type: "integer"},
+				},
+			},
+			Deprecated: true,
+		},
+		broom.Operation{
+			ID:     "create-product",
+			Method: "POST",
+			Path:   "/v2/products",
+			Parameters: broom.Parameters{
+				Body: broom.ParameterList{
+					{In: "body", Name: "name", Type: "string"},
+					{In: "body", Name: "price", Type: "string", Required: true},
+					{In: "body", Name: "currency_code", Type: "string", Required: true},
+				},
+			},
+			BodyFormat: "application/json",
+			Bodies: []broom.Body{
+				{Format: "application/json"},
+				{Format: "application/x-www-form-urlencoded"},
+			},
+		},
+		broom.Operation{
+			ID:     "get-product",
+			Method: "GET",
+			Path:   "/products/{product_id}",
+		},
+	}
+
+	gotChanges := broom.DiffOperations(oldOps, newOps)
+	wantChanges := []broom.Change{
+		{OperationID: "list-products", Message: "operation deprecated"},
+		{OperationID: "list-products", Message: `query parameter "sort" now only accepts: name`, Breaking: true},
+		{OperationID: "list-products", Message: `query parameter "page" changed type from string to integer`, Breaking: true},
+		{OperationID: "list-products", Message: `added query parameter "limit"`},
+		{OperationID: "create-product", Message: "changed from POST /products to POST /v2/products", Breaking: true},
+		{OperationID: "create-product", Message: `body parameter "name" is no longer required`},
+		{OperationID: "create-product", Message: `body parameter "price" is now required`, Breaking: true},
+		{OperationID: "create-product", Message: `added required body parameter "currency_code"`, Breaking: true},
+		{OperationID: "create-product", Message: "removed content type application/xml", Breaking: true},
+		{OperationID: "create-product", Message: "added content type application/x-www-form-urlencoded"},
+		{OperationID: "delete-product", Message: "operation removed", Breaking: true},
+		{OperationID: "get-product", Message: "operation added"},
+	}
+	if diff := cmp.Diff(wantChanges, gotChanges); diff != "" {
+		t.Errorf("change mismatch (-want +got):\n%s", diff)
+	}
+
+	// No changes.
+	gotChanges = broom.DiffOperations(oldOps, oldOps)
+	if len(gotChanges) != 0 {
+		t.Errorf("got %v, want no changes", gotChanges)
+	}
+}
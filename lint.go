@@ -0,0 +1,334 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/index"
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+// LintIssue represents a problem found in a specification or profile.
+type LintIssue struct {
+	// Line is the line number in the specification, or 0 if unknown.
+	Line int
+	// Location is the path to the problematic element, e.g. paths./products.get.
+	Location string
+	Message  string
+}
+
+// String returns the string representation of the issue.
+func (i LintIssue) String() string {
+	var sb strings.Builder
+	if i.Line > 0 {
+		fmt.Fprintf(&sb, "line %d: ", i.Line)
+	}
+	if i.Location != "" {
+		sb.WriteString(i.Location + ": ")
+	}
+	sb.WriteString(i.Message)
+
+	return sb.String()
+}
+
+// Lint checks a specification for problems that prevent Broom from using it.
+//
+// The specification can be on disk or at a remote URL.
+// An error is returned only if the specification could not be read.
+func Lint(filename string) ([]LintIssue, error) {
+	b, err := readSpec(filename)
+	if err != nil {
+		return nil, fmt.Errorf("load spec: %w", err)
+	}
+	l := &linter{}
+	spec, errs := buildSpec(filename, b)
+	for _, err := range errs {
+		l.addError(err)
+	}
+	if spec == nil {
+		return l.issues, nil
+	}
+	l.lintOperations(spec)
+	if spec.Components != nil {
+		for pair := orderedmap.First(spec.Components.Schemas); pair != nil; pair = pair.Next() {
+			l.lintSchema("components.schemas."+pair.Key(), pair.Value(), true)
+		}
+		for pair := orderedmap.First(spec.Components.SecuritySchemes); pair != nil; pair = pair.Next() {
+			l.lintSecurityScheme("components.securitySchemes."+pair.Key(), pair.Value())
+		}
+	}
+
+	return l.issues, nil
+}
+
+// LintProfile checks a profile for problems that prevent Broom from using it.
+//
+// The issue locations are relative to the config file (e.g. api.server_url).
+func LintProfile(profile string, profileCfg ProfileConfig) []LintIssue {
+	var issues []LintIssue
+	if profileCfg.ServerURL == "" {
+		issues = append(issues, LintIssue{
+			Location: profile + ".server_url",
+			Message:  "missing server url, operations can't be run",
+		})
+	} else if _, err := ExpandServerURL(profileCfg.ServerURL, profileCfg.ServerVariables); err != nil {
+		issues = append(issues, LintIssue{
+			Location: profile + ".server_url",
+			Message:  err.Error(),
+		})
+	}
+	issues = append(issues, lintAuth(profile+".auth", profileCfg.Auth)...)
+	for i, authCfg := range profileCfg.AdditionalAuth {
+		location := fmt.Sprintf("%v.additional_auth[%d]", profile, i)
+		if authCfg.Scheme == "" {
+			issues = append(issues, LintIssue{
+				Location: location + ".scheme",
+				Message:  "missing security scheme, additional auth can't be used",
+			})
+		}
+		issues = append(issues, lintAuth(location, authCfg)...)
+	}
+
+	return issues
+}
+
+// lintAuth checks the given auth config for problems.
+func lintAuth(location string, authCfg AuthConfig) []LintIssue {
+	var issues []LintIssue
+	if authCfg.Type != "" && !slices.Contains(AuthTypes(), authCfg.Type) {
+		issues = append(issues, LintIssue{
+			Location: location + ".type",
+			Message:  fmt.Sprintf("unrecognized auth type %q, must be one of: %v", authCfg.Type, strings.Join(AuthTypes(), ", ")),
+		})
+	}
+	if authCfg.Type == "api-key-cookie" && authCfg.APIKeyCookie == "" {
+		issues = append(issues, LintIssue{
+			Location: location + ".api_key_cookie",
+			Message:  "missing API key cookie, required by the api-key-cookie auth type",
+		})
+	}
+	if authCfg.Type == "api-key-query" && authCfg.APIKeyQuery == "" {
+		issues = append(issues, LintIssue{
+			Location: location + ".api_key_query",
+			Message:  "missing API key query parameter, required by the api-key-query auth type",
+		})
+	}
+	if authCfg.Type == "oauth2-client-credentials" || authCfg.Type == "oauth2-auth-code" {
+		if authCfg.TokenURL == "" {
+			issues = append(issues, LintIssue{
+				Location: location + ".token_url",
+				Message:  fmt.Sprintf("missing token URL, required by the %v auth type", authCfg.Type),
+			})
+		}
+		if authCfg.ClientID == "" {
+			issues = append(issues, LintIssue{
+				Location: location + ".client_id",
+				Message:  fmt.Sprintf("missing client ID, required by the %v auth type", authCfg.Type),
+			})
+		}
+	}
+	if authCfg.Type == "oauth2-auth-code" && authCfg.AuthorizationURL == "" {
+		issues = append(issues, LintIssue{
+			Location: location + ".authorization_url",
+			Message:  "missing authorization URL, required by the oauth2-auth-code auth type",
+		})
+	}
+
+	return issues
+}
+
+// linter collects issues found in a specification.
+type linter struct {
+	issues []LintIssue
+}
+
+// add adds a new issue.
+func (l *linter) add(line int, location string, format string, a ...any) {
+	l.issues = append(l.issues, LintIssue{
+		Line:     line,
+		Location: location,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// addError adds an issue for an error returned while building the spec.
+//
+// Indexing and resolving errors (e.g. unresolvable references) contain
+// the line at which they occurred.
+func (l *linter) addError(err error) {
+	var indexingErr *index.IndexingError
+	var resolvingErr *index.ResolvingError
+	if errors.As(err, &indexingErr) && indexingErr.Node != nil {
+		l.add(indexingErr.Node.Line, indexingErr.Path, "%v", indexingErr.Err)
+	} else if errors.As(err, &resolvingErr) && resolvingErr.Node != nil {
+		l.add(resolvingErr.Node.Line, resolvingErr.Path, "%v", resolvingErr.ErrorRef)
+	} else {
+		l.add(0, "", "%v", err)
+	}
+}
+
+// lintOperations checks the spec's operations.
+//
+// Operations are checked in the same order in which LoadOperations loads them.
+func (l *linter) lintOperations(spec *v3.Document) {
+	if spec.Paths == nil {
+		return
+	}
+	type opRef struct {
+		method string
+		path   string
+	}
+	opIDs := make(map[string]opRef)
+	for pair := orderedmap.First(spec.Paths.PathItems); pair != nil; pair = pair.Next() {
+		path := pair.Key()
+		pathItem := pair.Value()
+		for _, po := range pathItemOperations(pathItem) {
+			location := "paths." + path + "." + strings.ToLower(po.method)
+			line := 0
+			if lowOp := po.specOp.GoLow(); lowOp != nil && lowOp.KeyNode != nil {
+				line = lowOp.KeyNode.Line
+			}
+
+			if id := strcase.ToKebab(po.specOp.OperationId); id != "" {
+				if ref, ok := opIDs[id]; ok {
+					l.add(line, location, "duplicate operation ID %q, also used by %v %v, a numeric suffix will be added", id, ref.method, ref.path)
+				} else {
+					opIDs[id] = opRef{po.method, path}
+				}
+			}
+
+			declared := make(map[string]struct{})
+			params := append(slices.Clone(pathItem.Parameters), po.specOp.Parameters...)
+			for i, param := range params {
+				if param == nil {
+					continue
+				}
+				if param.In == "path" {
+					declared[param.Name] = struct{}{}
+				}
+				// Path-level parameters come first.
+				paramLocation := fmt.Sprintf("paths.%v.parameters.%d", path, i)
+				if i >= len(pathItem.Parameters) {
+					paramLocation = fmt.Sprintf("%v.parameters.%d", location, i-len(pathItem.Parameters))
+				}
+				if param.Schema == nil {
+					l.add(paramLine(param), paramLocation, "parameter %q has no schema", param.Name)
+					continue
+				}
+				l.lintSchema(paramLocation+".schema", param.Schema, false)
+			}
+			for _, match := range serverVariablePattern.FindAllStringSubmatch(path, -1) {
+				if _, ok := declared[match[1]]; !ok {
+					l.add(line, location, "path parameter %q is not declared", match[1])
+				}
+			}
+
+			if po.specOp.RequestBody != nil {
+				for bodyPair := orderedmap.First(po.specOp.RequestBody.Content); bodyPair != nil; bodyPair = bodyPair.Next() {
+					format := bodyPair.Key()
+					mediaType := bodyPair.Value()
+					bodyLocation := location + ".requestBody.content." + format
+					if !isSupportedBodyFormat(format) {
+						l.add(line, bodyLocation, "unsupported body format %q, the body can only be sent as-is via --body-file", format)
+					}
+					if mediaType.Schema != nil {
+						l.lintSchema(bodyLocation+".schema", mediaType.Schema, false)
+					}
+				}
+			}
+		}
+	}
+}
+
+// lintSchema checks the given schema and its inline subschemas.
+//
+// References are not followed, since the referenced schemas
+// are checked as part of the components.
+func (l *linter) lintSchema(location string, schemaProxy *base.SchemaProxy, isComponent bool) {
+	if schemaProxy == nil || (schemaProxy.IsReference() && !isComponent) {
+		return
+	}
+	schema := schemaProxy.Schema()
+	if schema == nil {
+		// The build error has already been reported.
+		return
+	}
+	line := 0
+	if lowSchemaProxy := schemaProxy.GoLow(); lowSchemaProxy != nil && lowSchemaProxy.GetValueNode() != nil {
+		line = lowSchemaProxy.GetValueNode().Line
+	}
+	isComposed := len(schema.AllOf) > 0 || len(schema.OneOf) > 0 || len(schema.AnyOf) > 0
+	if len(schema.Type) == 0 && schema.Properties == nil && schema.Items == nil && !isComposed {
+		l.add(line, location, "schema has no type, values will be sent as strings")
+	}
+	for pair := orderedmap.First(schema.Properties); pair != nil; pair = pair.Next() {
+		l.lintSchema(location+".properties."+pair.Key(), pair.Value(), false)
+	}
+	if schema.Items != nil && schema.Items.IsA() {
+		l.lintSchema(location+".items", schema.Items.A, false)
+	}
+	for i, subSchema := range schema.AllOf {
+		l.lintSchema(fmt.Sprintf("%v.allOf.%d", location, i), subSchema, false)
+	}
+	for i, subSchema := range schema.OneOf {
+		l.lintSchema(fmt.Sprintf("%v.oneOf.%d", location, i), subSchema, false)
+	}
+	for i, subSchema := range schema.AnyOf {
+		l.lintSchema(fmt.Sprintf("%v.anyOf.%d", location, i), subSchema, false)
+	}
+}
+
+// lintSecurityScheme checks whether the given security scheme is supported.
+func (l *linter) lintSecurityScheme(location string, scheme *v3.SecurityScheme) {
+	if isSupportedSecurityScheme(scheme) {
+		return
+	}
+	line := 0
+	if lowScheme := scheme.GoLow(); lowScheme != nil && lowScheme.KeyNode != nil {
+		line = lowScheme.KeyNode.Line
+	}
+	description := scheme.Type
+	switch scheme.Type {
+	case "http":
+		description += " " + scheme.Scheme
+	case "apiKey":
+		description += " in " + scheme.In
+	}
+	l.add(line, location, "unsupported auth scheme %q, credentials must be passed via -H", description)
+}
+
+// isSupportedBodyFormat checks whether Broom can build a body of the given format.
+func isSupportedBodyFormat(format string) bool {
+	return IsJSON(format) || format == "application/x-www-form-urlencoded" || format == "multipart/form-data"
+}
+
+// isSupportedSecurityScheme checks whether the given security scheme maps to a Broom auth type.
+func isSupportedSecurityScheme(scheme *v3.SecurityScheme) bool {
+	switch scheme.Type {
+	case "http":
+		return scheme.Scheme == "bearer" || scheme.Scheme == "basic"
+	case "apiKey":
+		return scheme.In == "header" || scheme.In == "cookie" || scheme.In == "query"
+	case "oauth2":
+		return scheme.Flows != nil && (scheme.Flows.ClientCredentials != nil || scheme.Flows.AuthorizationCode != nil)
+	}
+
+	return false
+}
+
+// paramLine returns the line at which the given parameter is defined, or 0 if unknown.
+func paramLine(param *v3.Parameter) int {
+	if lowParam := param.GoLow(); lowParam != nil && lowParam.RootNode != nil {
+		return lowParam.RootNode.Line
+	}
+
+	return 0
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom_test
+
+import (
+	"testing"
+
+	"github.com/bojanz/broom"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLint(t *testing.T) {
+	issues, err := broom.Lint("testdata/lint.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	gotIssues := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		gotIssues = append(gotIssues, issue.String())
+	}
+	wantIssues := []string{
+		`line 26: $.components.schemas.Missing: component '#/components/schemas/Missing' does not exist in the specification`,
+		`line 11: paths./products.get.parameters.0: parameter "filter" has no schema`,
+		`line 20: paths./products.post: duplicate operation ID "list-products", also used by GET /products, a numeric suffix will be added`,
+		`line 31: paths./products/import.post.requestBody.content.application/xml: unsupported body format "application/xml", the body can only be sent as-is via --body-file`,
+		`line 42: paths./products/{product_id}.get: path parameter "product_id" is not declared`,
+		`line 55: components.schemas.Product.properties.metadata: schema has no type, values will be sent as strings`,
+		`line 57: components.securitySchemes.oauth: unsupported auth scheme "oauth2", credentials must be passed via -H`,
+	}
+	if diff := cmp.Diff(wantIssues, gotIssues); diff != "" {
+		t.Errorf("issue mismatch (-want +got):\n%s", diff)
+	}
+
+	// A valid spec has no issues.
+	issues, err = broom.Lint("testdata/openapi3.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("got %v, want no issues", issues)
+	}
+
+	_, err = broom.Lint("testdata/missing.yaml")
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestLintProfile(t *testing.T) {
+	profileCfg := broom.ProfileConfig{
+		SpecFile:  "openapi.yaml",
+		ServerURL: "https://{region}.my-api.io",
+		Auth: broom.AuthConfig{
+			Type: "digest",
+		},
+	}
+	gotIssues := broom.LintProfile("api", profileCfg)
+	wantIssues := []broom.LintIssue{
+		{Location: "api.server_url", Message: "missing value for server variables: region"},
+		{Location: "api.auth.type", Message: `unrecognized auth type "digest", must be one of: bearer, basic, api-key, api-key-cookie, api-key-query, oauth2-client-credentials, oauth2-auth-code`},
+	}
+	if diff := cmp.Diff(wantIssues, gotIssues); diff != "" {
+		t.Errorf("issue mismatch (-want +got):\n%s", diff)
+	}
+
+	profileCfg.ServerURL = ""
+	profileCfg.Auth.Type = "bearer"
+	gotIssues = broom.LintProfile("api", profileCfg)
+	wantIssues = []broom.LintIssue{
+		{Location: "api.server_url", Message: "missing server url, operations can't be run"},
+	}
+	if diff := cmp.Diff(wantIssues, gotIssues); diff != "" {
+		t.Errorf("issue mismatch (-want +got):\n%s", diff)
+	}
+
+	profileCfg.ServerURL = "https://my-api.io"
+	profileCfg.Auth.Type = "api-key-cookie"
+	gotIssues = broom.LintProfile("api", profileCfg)
+	wantIssues = []broom.LintIssue{
+		{Location: "api.auth.api_key_cookie", Message: "missing API key cookie, required by the api-key-cookie auth type"},
+	}
+	if diff := cmp.Diff(wantIssues, gotIssues); diff != "" {
+		t.Errorf("issue mismatch (-want +got):\n%s", diff)
+	}
+
+	profileCfg.Auth.Type = "api-key-query"
+	gotIssues = broom.LintProfile("api", profileCfg)
+	wantIssues = []broom.LintIssue{
+		{Location: "api.auth.api_key_query", Message: "missing API key query parameter, required by the api-key-query auth type"},
+	}
+	if diff := cmp.Diff(wantIssues, gotIssues); diff != "" {
+		t.Errorf("issue mismatch (-want +got):\n%s", diff)
+	}
+
+	profileCfg.Auth.APIKeyQuery = "api_key"
+	profileCfg.ServerVariables = map[string]string{"region": "eu"}
+	profileCfg.ServerURL = "https://{region}.my-api.io"
+	gotIssues = broom.LintProfile("api", profileCfg)
+	if len(gotIssues) != 0 {
+		t.Errorf("got %v, want no issues", gotIssues)
+	}
+
+	profileCfg.Auth = broom.AuthConfig{Type: "oauth2-client-credentials"}
+	gotIssues = broom.LintProfile("api", profileCfg)
+	wantIssues = []broom.LintIssue{
+		{Location: "api.auth.token_url", Message: "missing token URL, required by the oauth2-client-credentials auth type"},
+		{Location: "api.auth.client_id", Message: "missing client ID, required by the oauth2-client-credentials auth type"},
+	}
+	if diff := cmp.Diff(wantIssues, gotIssues); diff != "" {
+		t.Errorf("issue mismatch (-want +got):\n%s", diff)
+	}
+
+	profileCfg.Auth = broom.AuthConfig{Type: "oauth2-auth-code", TokenURL: "https://auth.my-api.io/token", ClientID: "my-client"}
+	gotIssues = broom.LintProfile("api", profileCfg)
+	wantIssues = []broom.LintIssue{
+		{Location: "api.auth.authorization_url", Message: "missing authorization URL, required by the oauth2-auth-code auth type"},
+	}
+	if diff := cmp.Diff(wantIssues, gotIssues); diff != "" {
+		t.Errorf("issue mismatch (-want +got):\n%s", diff)
+	}
+
+	profileCfg.Auth = broom.AuthConfig{Type: "bearer"}
+	profileCfg.AdditionalAuth = []broom.AuthConfig{
+		{Type: "api-key-cookie"},
+	}
+	gotIssues = broom.LintProfile("api", profileCfg)
+	wantIssues = []broom.LintIssue{
+		{Location: "api.additional_auth[0].scheme", Message: "missing security scheme, additional auth can't be used"},
+		{Location: "api.additional_auth[0].api_key_cookie", Message: "missing API key cookie, required by the api-key-cookie auth type"},
+	}
+	if diff := cmp.Diff(wantIssues, gotIssues); diff != "" {
+		t.Errorf("issue mismatch (-want +got):\n%s", diff)
+	}
+}
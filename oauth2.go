@@ -0,0 +1,385 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tokenExpiryDelta is how early a cached token is considered expired,
+// to avoid it expiring while the request is in flight.
+const tokenExpiryDelta = 30 * time.Second
+
+// defaultTokenLifetime is the assumed lifetime of tokens received without
+// an expires_in, which RFC 6749 only recommends. Once it passes, a new token
+// is requested (or the token is refreshed), instead of using it indefinitely.
+const defaultTokenLifetime = 1 * time.Hour
+
+// defaultRedirectURL is the redirect URL used by Login when none is configured.
+const defaultRedirectURL = "http://127.0.0.1:8085/callback"
+
+// loginTimeout is how long Login waits for the user to log in.
+const loginTimeout = 5 * time.Minute
+
+// ErrLoginRequired is returned by Authenticate when the oauth2-auth-code
+// auth type has no usable token, and the user needs to log in via Login.
+var ErrLoginRequired = errors.New("login required")
+
+// oauth2Token represents an OAuth2 access token.
+type oauth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// valid returns whether the token can still be used.
+func (t oauth2Token) valid() bool {
+	return t.AccessToken != "" && time.Now().Add(tokenExpiryDelta).Before(t.ExpiresAt)
+}
+
+// oauth2ClientCredentialsToken returns an access token for the given auth config,
+// using the OAuth2 client credentials flow.
+//
+// Tokens are cached until they expire, after which a new one is requested.
+// The client secret is only retrieved when a new token is needed, so that
+// the auth command doesn't need to run on every request.
+func oauth2ClientCredentialsToken(cfg AuthConfig) (string, error) {
+	if cfg.TokenURL == "" {
+		return "", errors.New("token URL not specified")
+	}
+	if cfg.ClientID == "" {
+		return "", errors.New("client ID not specified")
+	}
+	cacheFilename, cacheErr := tokenCacheFilename(cfg)
+	if cacheErr == nil {
+		if token, err := readCachedToken(cacheFilename); err == nil && token.valid() {
+			return token.AccessToken, nil
+		}
+	}
+	clientSecret, err := cfg.credentials()
+	if err != nil {
+		return "", err
+	}
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+	if len(cfg.Scopes) > 0 {
+		data.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	if cfg.Audience != "" {
+		data.Set("audience", cfg.Audience)
+	}
+	token, err := requestToken(cfg.TokenURL, cfg.ClientID, clientSecret, data)
+	if err != nil {
+		return "", err
+	}
+	if cacheErr == nil {
+		// Caching is an optimization, it's fine to proceed without it.
+		writeCachedToken(cacheFilename, token)
+	}
+
+	return token.AccessToken, nil
+}
+
+// oauth2AuthCodeToken returns an access token for the given auth config,
+// previously obtained via Login.
+//
+// Expired tokens are refreshed using the refresh token, when available.
+// Returns ErrLoginRequired if there is no usable token.
+func oauth2AuthCodeToken(cfg AuthConfig) (string, error) {
+	cacheFilename, err := tokenCacheFilename(cfg)
+	if err != nil {
+		return "", fmt.Errorf("cache dir: %w", err)
+	}
+	token, err := readCachedToken(cacheFilename)
+	if err != nil {
+		return "", ErrLoginRequired
+	}
+	if token.valid() {
+		return token.AccessToken, nil
+	}
+	if token.RefreshToken == "" {
+		return "", ErrLoginRequired
+	}
+	clientSecret, err := cfg.credentials()
+	if err != nil {
+		return "", err
+	}
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", token.RefreshToken)
+	newToken, err := requestToken(cfg.TokenURL, cfg.ClientID, clientSecret, data)
+	if err != nil {
+		// The refresh token might have expired or been revoked.
+		return "", fmt.Errorf("%w: %v", ErrLoginRequired, err)
+	}
+	// The refresh token is not always rotated.
+	if newToken.RefreshToken == "" {
+		newToken.RefreshToken = token.RefreshToken
+	}
+	writeCachedToken(cacheFilename, newToken)
+
+	return newToken.AccessToken, nil
+}
+
+// Login logs in using the OAuth2 authorization code flow with PKCE,
+// for use with the oauth2-auth-code auth type.
+//
+// A temporary server is started on the redirect URL to receive the authorization
+// code, and the given function is called with the authorization URL, which the
+// user needs to open in their browser. The obtained tokens are stored for use
+// by Authenticate, separately for each profile.
+func Login(cfg AuthConfig, openURL func(authURL string)) error {
+	if cfg.AuthorizationURL == "" {
+		return errors.New("authorization URL not specified")
+	}
+	if cfg.TokenURL == "" {
+		return errors.New("token URL not specified")
+	}
+	if cfg.ClientID == "" {
+		return errors.New("client ID not specified")
+	}
+	redirectURL := cfg.RedirectURL
+	if redirectURL == "" {
+		redirectURL = defaultRedirectURL
+	}
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		return fmt.Errorf("parse redirect URL: %w", err)
+	}
+	if u.Scheme != "http" {
+		return fmt.Errorf("redirect URL %v must use http", redirectURL)
+	}
+	listener, err := net.Listen("tcp", u.Host)
+	if err != nil {
+		return fmt.Errorf("start callback server: %w", err)
+	}
+	defer listener.Close()
+
+	verifier := randomString(32)
+	challenge := sha256.Sum256([]byte(verifier))
+	state := randomString(16)
+	authURL, err := url.Parse(cfg.AuthorizationURL)
+	if err != nil {
+		return fmt.Errorf("parse authorization URL: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", cfg.ClientID)
+	query.Set("redirect_uri", redirectURL)
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if len(cfg.Scopes) > 0 {
+		query.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	if cfg.Audience != "" {
+		query.Set("audience", cfg.Audience)
+	}
+	authURL.RawQuery = query.Encode()
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+	callbackPath := u.Path
+	if callbackPath == "" {
+		callbackPath = "/"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		result := callbackResult{code: query.Get("code")}
+		switch {
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("authorization failed: %v", query.Get("error"))
+			if query.Get("error_description") != "" {
+				result.err = fmt.Errorf("%w: %v", result.err, query.Get("error_description"))
+			}
+		case query.Get("state") != state:
+			result.err = errors.New("authorization failed: state mismatch")
+		case result.code == "":
+			result.err = errors.New("authorization failed: no code received")
+		}
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Logged in. You can close this window.")
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	defer server.Close()
+
+	openURL(authURL.String())
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-time.After(loginTimeout):
+		return errors.New("login timed out")
+	}
+	if result.err != nil {
+		return result.err
+	}
+	clientSecret, err := cfg.credentials()
+	if err != nil {
+		return err
+	}
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", result.code)
+	data.Set("redirect_uri", redirectURL)
+	data.Set("code_verifier", verifier)
+	token, err := requestToken(cfg.TokenURL, cfg.ClientID, clientSecret, data)
+	if err != nil {
+		return err
+	}
+	cacheFilename, err := tokenCacheFilename(cfg)
+	if err != nil {
+		return fmt.Errorf("cache dir: %w", err)
+	}
+	if err := writeCachedToken(cacheFilename, token); err != nil {
+		return fmt.Errorf("store token: %w", err)
+	}
+
+	return nil
+}
+
+// requestToken requests an access token from the given token URL.
+//
+// The client credentials are sent via Basic auth, as recommended by RFC 6749.
+// Public clients (without a client secret) send their client ID in the body.
+func requestToken(tokenURL string, clientID string, clientSecret string, data url.Values) (oauth2Token, error) {
+	if clientSecret == "" {
+		data.Set("client_id", clientID)
+	}
+	req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	var tokenResp struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	jsonErr := json.Unmarshal(b, &tokenResp)
+	if resp.StatusCode != http.StatusOK {
+		if jsonErr == nil && tokenResp.Error != "" {
+			if tokenResp.ErrorDescription != "" {
+				return oauth2Token{}, fmt.Errorf("token request failed: %v: %v", tokenResp.Error, tokenResp.ErrorDescription)
+			}
+			return oauth2Token{}, fmt.Errorf("token request failed: %v", tokenResp.Error)
+		}
+		return oauth2Token{}, fmt.Errorf("token request failed: %v", resp.Status)
+	}
+	if jsonErr != nil {
+		return oauth2Token{}, fmt.Errorf("token request failed: invalid response: %w", jsonErr)
+	}
+	if tokenResp.AccessToken == "" {
+		return oauth2Token{}, errors.New("token request failed: no access token received")
+	}
+	expiresIn := defaultTokenLifetime
+	if tokenResp.ExpiresIn > 0 {
+		expiresIn = time.Duration(tokenResp.ExpiresIn) * time.Second
+	}
+	token := oauth2Token{
+		AccessToken:  tokenResp.AccessToken,
+		TokenType:    tokenResp.TokenType,
+		RefreshToken: tokenResp.RefreshToken,
+		ExpiresAt:    time.Now().Add(expiresIn),
+	}
+
+	return token, nil
+}
+
+// tokenCacheFilename returns the name of the token cache file for the given auth config.
+//
+// Each combination of profile, token URL, client ID, scopes, and audience has its own token.
+func tokenCacheFilename(cfg AuthConfig) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	key := strings.Join([]string{cfg.Profile, cfg.Type, cfg.TokenURL, cfg.ClientID, strings.Join(cfg.Scopes, " "), cfg.Audience}, "\n")
+	hash := sha256.Sum256([]byte(key))
+
+	return filepath.Join(dir, "tokens", hex.EncodeToString(hash[:])+".json"), nil
+}
+
+// readCachedToken reads a cached token.
+func readCachedToken(filename string) (oauth2Token, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	token := oauth2Token{}
+	if err := json.Unmarshal(b, &token); err != nil {
+		return oauth2Token{}, err
+	}
+
+	return token, nil
+}
+
+// writeCachedToken writes a token to the cache.
+//
+// The token is only readable by the current user.
+func writeCachedToken(filename string, token oauth2Token) error {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, b, 0600)
+}
+
+// randomString returns a random URL-safe string, generated from n random bytes.
+func randomString(n int) string {
+	b := make([]byte, n)
+	// As of Go 1.20, rand.Read only fails if the system's entropy source is broken.
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
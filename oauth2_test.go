@@ -0,0 +1,152 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/bojanz/broom"
+)
+
+func TestLogin(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	codeChallenge := ""
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		r.ParseForm()
+		if r.PostForm.Get("client_id") != "my-client" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_client"}`))
+			return
+		}
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if r.PostForm.Get("code") != "MYCODE" || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != codeChallenge {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "invalid_grant"}`))
+				return
+			}
+			// A short lifetime ensures that the token is refreshed on first use.
+			w.Write([]byte(`{"access_token": "TOKEN1", "refresh_token": "REFRESH1", "expires_in": 10}`))
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "REFRESH1" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "invalid_grant"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token": "TOKEN2", "expires_in": 3600}`))
+		}
+	}))
+	defer server.Close()
+	authCfg := broom.AuthConfig{
+		Type:             "oauth2-auth-code",
+		AuthorizationURL: "https://auth.my-api.io/authorize",
+		TokenURL:         server.URL + "/token",
+		ClientID:         "my-client",
+		Scopes:           []string{"openid", "read"},
+		RedirectURL:      fmt.Sprintf("http://127.0.0.1:%d/callback", freePort(t)),
+		Profile:          "api",
+	}
+
+	// No token yet.
+	req, _ := http.NewRequest("GET", "/test", nil)
+	err := broom.Authenticate(req, authCfg)
+	if !errors.Is(err, broom.ErrLoginRequired) {
+		t.Errorf("got %v, want %v", err, broom.ErrLoginRequired)
+	}
+
+	err = broom.Login(authCfg, func(authURL string) {
+		u, _ := url.Parse(authURL)
+		query := u.Query()
+		if query.Get("response_type") != "code" || query.Get("client_id") != "my-client" || query.Get("scope") != "openid read" {
+			t.Errorf("unexpected authorization URL %v", authURL)
+		}
+		if query.Get("code_challenge_method") != "S256" || query.Get("redirect_uri") != authCfg.RedirectURL {
+			t.Errorf("unexpected authorization URL %v", authURL)
+		}
+		codeChallenge = query.Get("code_challenge")
+		// Simulate the user logging in.
+		resp, err := http.Get(authCfg.RedirectURL + "?code=MYCODE&state=" + url.QueryEscape(query.Get("state")))
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			return
+		}
+		resp.Body.Close()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// The stored token is refreshed, then reused.
+	for i := 0; i < 2; i++ {
+		req, _ = http.NewRequest("GET", "/test", nil)
+		err = broom.Authenticate(req, authCfg)
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+		got := req.Header.Get("Authorization")
+		want := "Bearer TOKEN2"
+		if got != want {
+			t.Errorf(`got %q, want %q`, got, want)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("got %v token requests, want 2", requests)
+	}
+
+	// Tokens are stored per profile.
+	authCfg.Profile = "staging"
+	req, _ = http.NewRequest("GET", "/test", nil)
+	err = broom.Authenticate(req, authCfg)
+	if !errors.Is(err, broom.ErrLoginRequired) {
+		t.Errorf("got %v, want %v", err, broom.ErrLoginRequired)
+	}
+}
+
+func TestLogin_Denied(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	authCfg := broom.AuthConfig{
+		Type:             "oauth2-auth-code",
+		AuthorizationURL: "https://auth.my-api.io/authorize",
+		TokenURL:         "https://auth.my-api.io/token",
+		ClientID:         "my-client",
+		RedirectURL:      fmt.Sprintf("http://127.0.0.1:%d/callback", freePort(t)),
+	}
+	err := broom.Login(authCfg, func(authURL string) {
+		u, _ := url.Parse(authURL)
+		state := u.Query().Get("state")
+		resp, err := http.Get(authCfg.RedirectURL + "?error=access_denied&error_description=User+denied+access&state=" + url.QueryEscape(state))
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			return
+		}
+		resp.Body.Close()
+	})
+	wantErr := "authorization failed: access_denied: User denied access"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("got %v, want %v", err, wantErr)
+	}
+}
+
+// freePort returns a free local port, for use as the callback port.
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
@@ -5,14 +5,29 @@ package broom
 
 import (
 	"bytes"
+	"cmp"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/mail"
+	"net/netip"
+	"net/textproto"
 	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/iancoleman/strcase"
 )
@@ -34,10 +49,13 @@ func (ops Operations) ByID(id string) (Operation, bool) {
 }
 
 // ByTag returns a list of operations for the given tag.
+//
+// Operations can have multiple tags, any of which can match.
+// Untagged operations are returned for an empty tag.
 func (ops Operations) ByTag(tag string) Operations {
 	filteredOps := make(Operations, 0, len(ops))
 	for _, op := range ops {
-		if op.Tag == tag {
+		if slices.Contains(op.Tags, tag) || (tag == "" && len(op.Tags) == 0) {
 			filteredOps = append(filteredOps, op)
 		}
 	}
@@ -45,33 +63,69 @@ func (ops Operations) ByTag(tag string) Operations {
 }
 
 // Tags returns a list of all available operation tags.
-func (ops Operations) Tags() []string {
-	tags := make(map[string]struct{})
+//
+// Tags defined by the spec are listed first, in the spec order, followed by
+// the remaining tags sorted alphabetically. Tags without operations are skipped.
+func (ops Operations) Tags(specTags []Tag) []Tag {
+	opTags := make(map[string]struct{})
 	for _, op := range ops {
-		tags[op.Tag] = struct{}{}
+		if len(op.Tags) == 0 {
+			opTags[""] = struct{}{}
+		}
+		for _, tag := range op.Tags {
+			opTags[tag] = struct{}{}
+		}
+	}
+	tags := make([]Tag, 0, len(opTags))
+	for _, tag := range specTags {
+		if _, ok := opTags[tag.Name]; ok {
+			tags = append(tags, tag)
+			delete(opTags, tag.Name)
+		}
 	}
-	tagNames := make([]string, 0, len(tags))
-	for tagName := range tags {
+	tagNames := make([]string, 0, len(opTags))
+	for tagName := range opTags {
 		tagNames = append(tagNames, tagName)
 	}
 	sort.Strings(tagNames)
+	for _, tagName := range tagNames {
+		tags = append(tags, Tag{Name: tagName})
+	}
+
+	return tags
+}
 
-	return tagNames
+// Tag represents an operation tag.
+type Tag struct {
+	Name        string
+	Description string
 }
 
 // Operation represents an available operation.
 type Operation struct {
-	ID          string
-	Summary     string
-	Description string
-	Tag         string
-	Method      string
-	Path        string
-	Parameters  Parameters
-	BodyFormat  string
-	Deprecated  bool
+	ID           string
+	Summary      string
+	Description  string
+	Tags         []string
+	Method       string
+	Path         string
+	Servers      []Server
+	Parameters   Parameters
+	BodyFormat   string
+	BodyVariants []BodyVariant
+	Bodies       []Body
+	Responses    []Response
+	// Security lists the alternative security requirements.
+	// Nil if the spec doesn't define any.
+	Security   []SecurityRequirement
+	Deprecated bool
 }
 
+// SecurityRequirement lists the names of security schemes that must be used together.
+//
+// An empty requirement means that authentication is optional.
+type SecurityRequirement []string
+
 // SummaryWithFlags returns the operation summary with flags.
 func (op Operation) SummaryWithFlags() string {
 	summary := op.Summary
@@ -82,30 +136,341 @@ func (op Operation) SummaryWithFlags() string {
 	return summary
 }
 
+// IsPublic returns whether the operation can be performed without authentication.
+func (op Operation) IsPublic() bool {
+	for _, req := range op.Security {
+		if len(req) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // HasBody returns whether the operation has a body.
 func (op Operation) HasBody() bool {
 	// Body params are keyed by format in the spec, so there's no need to check both.
 	return op.BodyFormat != ""
 }
 
+// ServerURL returns the server URL to use for the operation.
+//
+// The spec can define servers for a specific path or operation, overriding
+// the given default server URL (usually taken from the profile).
+// Server variables are replaced using the given values.
+func (op Operation) ServerURL(defaultURL string, values map[string]string) (string, error) {
+	if len(op.Servers) > 0 {
+		serverURL, err := op.Servers[0].ResolveURL(values)
+		if err != nil {
+			return "", err
+		}
+		if defaultURL, err = ExpandServerURL(defaultURL, values); err != nil {
+			// The default server URL is only needed for relative server URLs.
+			return serverURL, nil
+		}
+		return joinServerURL(defaultURL, serverURL), nil
+	}
+
+	return ExpandServerURL(defaultURL, values)
+}
+
+// WithBodyFormat returns a copy of the operation with the given body format selected.
+//
+// The format can be specified in full (application/x-www-form-urlencoded),
+// or partially (form), as long as only one body format matches.
+func (op Operation) WithBodyFormat(format string) (Operation, error) {
+	if len(op.Bodies) == 0 {
+		return Operation{}, fmt.Errorf("operation %v has no body", op.ID)
+	}
+	formats := make([]string, 0, len(op.Bodies))
+	for _, body := range op.Bodies {
+		formats = append(formats, body.Format)
+	}
+	i := slices.Index(formats, format)
+	if i == -1 {
+		for j, f := range formats {
+			if strings.Contains(f, format) {
+				if i != -1 {
+					return Operation{}, fmt.Errorf("ambiguous body format %q, must be one of: %v", format, strings.Join(formats, ", "))
+				}
+				i = j
+			}
+		}
+	}
+	if i == -1 {
+		return Operation{}, fmt.Errorf("unsupported body format %q, must be one of: %v", format, strings.Join(formats, ", "))
+	}
+	body := op.Bodies[i]
+	op.BodyFormat = body.Format
+	op.BodyVariants = body.Variants
+	op.Parameters.Body = body.Parameters
+
+	return op, nil
+}
+
+// BodyVariant returns the body variant with the given name.
+func (op Operation) BodyVariant(name string) (BodyVariant, bool) {
+	for _, v := range op.BodyVariants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return BodyVariant{}, false
+}
+
+// WithBodyVariant returns a copy of the operation with the given body variant applied.
+//
+// The variant's parameters are added to the body parameters, replacing
+// any existing parameters with the same name.
+func (op Operation) WithBodyVariant(name string) (Operation, error) {
+	if len(op.BodyVariants) == 0 {
+		return Operation{}, fmt.Errorf("operation %v has no body variants", op.ID)
+	}
+	variant, ok := op.BodyVariant(name)
+	if !ok {
+		names := make([]string, 0, len(op.BodyVariants))
+		for _, v := range op.BodyVariants {
+			names = append(names, v.Name)
+		}
+		return Operation{}, fmt.Errorf("unknown body variant %q, must be one of: %v", name, strings.Join(names, ", "))
+	}
+	body := slices.Clone(op.Parameters.Body)
+	for _, p := range variant.Parameters {
+		i := slices.IndexFunc(body, func(bp Parameter) bool {
+			return bp.Name == p.Name
+		})
+		if i != -1 {
+			body[i] = p
+		} else {
+			body = append(body, p)
+		}
+	}
+	op.Parameters.Body = body
+
+	return op, nil
+}
+
 // Validate validates the given values against the operation's parameters.
+//
+// Returns a *ValidationError listing all required parameters without a value.
+// Parameters with a default value are not considered missing, since the server
+// is expected to use the default. Raw bodies are inspected for required
+// body parameters, unless they are in an unsupported format.
 func (op Operation) Validate(values RequestValues) error {
-	nParams := len(op.Parameters.Path)
-	nValues := len(values.Path)
-	if nParams > nValues {
-		return fmt.Errorf("too few path parameters: got %v, want %v", nValues, nParams)
+	var missing []Parameter
+	for i, p := range op.Parameters.Path {
+		// Path parameters are always required.
+		if i >= len(values.Path) {
+			missing = append(missing, p)
+		}
+	}
+	for _, p := range op.Parameters.Header {
+		if p.Required && p.Default == "" && values.Header.Get(p.Name) == "" {
+			missing = append(missing, p)
+		}
+	}
+	for _, p := range op.Parameters.Cookie {
+		if p.Required && p.Default == "" && !values.Cookie.Has(p.Name) {
+			missing = append(missing, p)
+		}
+	}
+	for _, p := range op.Parameters.Query {
+		if p.Required && p.Default == "" && !values.Query.Has(p.Name) {
+			missing = append(missing, p)
+		}
+	}
+	if op.HasBody() {
+		bodyNames, ok := op.bodyNames(values.RawBody, values.Body)
+		for _, p := range op.Parameters.Body {
+			// Array item parameters (e.g. items[].sku) are not checked, since
+			// there is no way to tell how many items the user intended to send.
+			if !ok || !p.Required || p.Default != "" || strings.Contains(p.Name, "[]") {
+				continue
+			}
+			// Nested parameters are only required if their parent object is present.
+			if i := strings.LastIndex(p.Name, "."); i != -1 && !hasBodyName(bodyNames, p.Name[:i]) {
+				continue
+			}
+			if !hasBodyName(bodyNames, p.Name) {
+				missing = append(missing, p)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return &ValidationError{Missing: missing}
 	}
 
 	return nil
 }
 
+// ValidateValues validates the given values against the parameter types, enums, and constraints.
+//
+// Returns a *ValidationError listing all parameters with an invalid value.
+func (op Operation) ValidateValues(values RequestValues) error {
+	var invalid []ParameterError
+	addInvalid := func(p Parameter, values []string) {
+		if err := p.ValidateValues(values); err != nil {
+			invalid = append(invalid, ParameterError{Parameter: p, Err: err})
+		}
+	}
+	for i, p := range op.Parameters.Path {
+		if i < len(values.Path) {
+			addInvalid(p, values.Path[i:i+1])
+		}
+	}
+	for _, p := range op.Parameters.Header {
+		if value := values.Header.Get(p.Name); value != "" {
+			addInvalid(p, []string{value})
+		}
+	}
+	for _, p := range op.Parameters.Cookie {
+		if values.Cookie.Has(p.Name) {
+			addInvalid(p, values.Cookie[p.Name])
+		}
+	}
+	for _, p := range op.Parameters.Query {
+		if values.Query.Has(p.Name) {
+			addInvalid(p, values.Query[p.Name])
+		}
+	}
+	bodyNames := make([]string, 0, len(values.Body))
+	for name := range values.Body {
+		bodyNames = append(bodyNames, name)
+	}
+	sort.Strings(bodyNames)
+	for _, name := range bodyNames {
+		p, ok := op.bodyParameter(name)
+		if !ok {
+			continue
+		}
+		bodyValues := values.Body[name]
+		if op.BodyFormat == "multipart/form-data" {
+			// Attached files are not validated.
+			bodyValues = slices.DeleteFunc(slices.Clone(bodyValues), func(v string) bool {
+				return strings.HasPrefix(v, "@")
+			})
+		}
+		// Report the name as given, including any array indexes.
+		p.Name = name
+		addInvalid(p, bodyValues)
+	}
+
+	if len(invalid) > 0 {
+		return &ValidationError{Invalid: invalid}
+	}
+
+	return nil
+}
+
+// bodyNames returns the names of all given body values, including those in the raw body.
+//
+// Nested JSON objects are flattened using dots (e.g. customer.email).
+// Returns false if the raw body could not be inspected.
+func (op Operation) bodyNames(rawBody []byte, bodyValues url.Values) (map[string]struct{}, bool) {
+	names := make(map[string]struct{}, len(bodyValues))
+	for name := range bodyValues {
+		name, _ = rawJSONName(name)
+		names[name] = struct{}{}
+	}
+	if rawBody == nil {
+		return names, true
+	}
+	if IsJSON(op.BodyFormat) {
+		var jsonValues map[string]any
+		if err := json.Unmarshal(rawBody, &jsonValues); err != nil {
+			return nil, false
+		}
+		flattenBodyNames(names, "", jsonValues)
+		return names, true
+	} else if op.BodyFormat == "application/x-www-form-urlencoded" {
+		formValues, err := url.ParseQuery(string(rawBody))
+		if err != nil {
+			return nil, false
+		}
+		for name := range formValues {
+			names[name] = struct{}{}
+		}
+		return names, true
+	}
+
+	return nil, false
+}
+
+// flattenBodyNames adds the keys of the given JSON object to names, recursively.
+func flattenBodyNames(names map[string]struct{}, prefix string, jsonValues map[string]any) {
+	for key, value := range jsonValues {
+		names[prefix+key] = struct{}{}
+		if m, ok := value.(map[string]any); ok {
+			flattenBodyNames(names, prefix+key+".", m)
+		}
+	}
+}
+
+// hasBodyName checks whether a value was given for the body parameter with the given name.
+//
+// Values given for nested parameters (e.g. customer.email) count as values for their parent.
+func hasBodyName(names map[string]struct{}, name string) bool {
+	if _, ok := names[name]; ok {
+		return true
+	}
+	for n := range names {
+		if strings.HasPrefix(n, name+".") || strings.HasPrefix(n, name+"[") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidationError is returned when parameter values are missing or invalid.
+type ValidationError struct {
+	Missing []Parameter
+	Invalid []ParameterError
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	var errs []string
+	if len(e.Missing) > 0 {
+		names := make([]string, 0, len(e.Missing))
+		for _, p := range e.Missing {
+			names = append(names, fmt.Sprintf("%v (%v)", p.Name, p.In))
+		}
+		errs = append(errs, "missing required parameters: "+strings.Join(names, ", "))
+	}
+	for _, pe := range e.Invalid {
+		errs = append(errs, pe.Error())
+	}
+
+	return strings.Join(errs, "; ")
+}
+
+// ParameterError represents an invalid parameter value.
+type ParameterError struct {
+	Parameter Parameter
+	Err       error
+}
+
+// Error implements the error interface.
+func (e ParameterError) Error() string {
+	return fmt.Sprintf("invalid %v (%v): %v", e.Parameter.Name, e.Parameter.In, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ParameterError) Unwrap() error {
+	return e.Err
+}
+
 // Request creates a new request with the given values.
+//
+// The values are not validated, allowing invalid requests to be sent on purpose.
+// Use Validate() and ValidateValues() to check them first.
 func (op Operation) Request(serverURL string, values RequestValues) (*http.Request, error) {
-	if err := op.Validate(values); err != nil {
+	url, err := op.requestURL(serverURL, values)
+	if err != nil {
 		return nil, err
 	}
-	url := op.requestURL(serverURL, values)
-	body, err := op.requestBody(values.Body)
+	body, contentType, err := op.requestBody(values.RawBody, values.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -114,94 +479,469 @@ func (op Operation) Request(serverURL string, values RequestValues) (*http.Reque
 		return nil, err
 	}
 	if len(values.Header) > 0 {
-		req.Header = values.Header
+		req.Header = values.Header.Clone()
 	}
-	if op.HasBody() {
-		req.Header.Set("Content-Type", op.BodyFormat)
+	for _, param := range op.Parameters.Header {
+		if value := req.Header.Get(param.Name); value != "" {
+			value, err = param.headerValue(value)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set(param.Name, value)
+		}
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	cookieNames := make([]string, 0, len(values.Cookie))
+	for name := range values.Cookie {
+		cookieNames = append(cookieNames, name)
+	}
+	sort.Strings(cookieNames)
+	for _, name := range cookieNames {
+		req.AddCookie(&http.Cookie{Name: name, Value: values.Cookie.Get(name)})
 	}
 	req.Header.Set("User-Agent", fmt.Sprintf("broom/%s (%s %s)", Version, runtime.GOOS, runtime.GOARCH))
 
 	return req, nil
 }
 
-// requestURL builds an absolute request url using the given body/query values.
-func (op Operation) requestURL(serverURL string, values RequestValues) string {
+// requestURL builds an absolute request url using the given path/query values.
+//
+// Path and query values are serialized according to the parameter style.
+func (op Operation) requestURL(serverURL string, values RequestValues) (string, error) {
 	oldnew := make([]string, 0, len(op.Parameters.Path)*2)
 	for i, v := range values.Path {
 		if i+1 > len(op.Parameters.Path) {
 			break
 		}
-		paramName := fmt.Sprintf("{%v}", op.Parameters.Path[i].Name)
-		oldnew = append(oldnew, paramName, v)
+		param := op.Parameters.Path[i]
+		value, err := param.pathValue(v)
+		if err != nil {
+			return "", err
+		}
+		oldnew = append(oldnew, fmt.Sprintf("{%v}", param.Name), value)
 	}
 	r := strings.NewReplacer(oldnew...)
 	path := r.Replace(op.Path)
 	if len(values.Query) > 0 {
-		path = path + "?" + values.Query.Encode()
+		names := make([]string, 0, len(values.Query))
+		for name := range values.Query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		query := make([]string, 0, len(names))
+		for _, name := range names {
+			// Non-defined parameters use the default style.
+			param, ok := op.Parameters.Query.ByName(name)
+			if !ok {
+				param = Parameter{In: "query", Name: name}
+			}
+			queryString, err := param.queryString(values.Query[name])
+			if err != nil {
+				return "", err
+			}
+			query = append(query, queryString)
+		}
+		path = path + "?" + strings.Join(query, "&")
 	}
 	// Paths always start with a slash, so make
 	// sure the server URL doesn't end with one.
 	serverURL = strings.TrimSuffix(serverURL, "/")
 
-	return serverURL + path
+	return serverURL + path, nil
 }
 
 // requestBody converts the given body values into a byte array suitable for sending.
-func (op Operation) requestBody(bodyValues url.Values) ([]byte, error) {
+//
+// The raw body, if any, is sent verbatim, unless there are body values to merge into it.
+// Returns the body and its content type.
+func (op Operation) requestBody(rawBody []byte, bodyValues url.Values) ([]byte, string, error) {
+	if rawBody != nil && len(bodyValues) == 0 {
+		return rawBody, op.BodyFormat, nil
+	}
 	if !op.HasBody() {
 		// Operation does not support specifying a body (e.g. GET/DELETE).
-		return nil, nil
+		return nil, "", nil
 	}
 
 	if IsJSON(op.BodyFormat) {
-		jsonValues := make(map[string]any, len(bodyValues))
+		names := make([]string, 0, len(bodyValues))
 		for name := range bodyValues {
-			value := bodyValues.Get(name)
-			// Allow defined parameters to cast the string.
-			if bodyParam, ok := op.Parameters.Body.ByName(name); ok {
-				var err error
-				jsonValues[name], err = bodyParam.CastString(value)
-				if err != nil {
-					return nil, fmt.Errorf("could not process %v: %v", name, err)
-				}
+			names = append(names, name)
+		}
+		// Process top-level names first, allowing nested values to replace them
+		// (e.g. infra.storage replacing infra).
+		slices.SortFunc(names, func(a, b string) int {
+			if c := cmp.Compare(len(splitBodyName(a)), len(splitBodyName(b))); c != 0 {
+				return c
 			}
-			// Pass through non-defined parameters as strings.
-			if _, ok := jsonValues[name]; !ok {
-				jsonValues[name] = value
+			return strings.Compare(a, b)
+		})
+		jsonValues := make(map[string]any, len(bodyValues))
+		if rawBody != nil {
+			// Numbers are decoded as json.Number to be re-encoded as-is.
+			d := json.NewDecoder(bytes.NewReader(rawBody))
+			d.UseNumber()
+			if err := d.Decode(&jsonValues); err != nil {
+				return nil, "", fmt.Errorf("could not merge body values: raw body is not a JSON object: %w", err)
 			}
 		}
-		// Process nested parameters.
-		for name, value := range jsonValues {
-			if !strings.Contains(name, ".") {
-				continue
+		for _, name := range names {
+			var value any
+			var err error
+			if jsonName, ok := rawJSONName(name); ok {
+				value, err = decodeRawJSON(bodyValues.Get(name))
+				name = jsonName
+			} else {
+				value, err = op.castBodyValue(name, bodyValues.Get(name))
 			}
-			nameParts := strings.Split(name, ".")
-			temp := jsonValues
-			for i, subname := range nameParts {
-				if i == len(nameParts)-1 {
-					temp[subname] = value
-					break
-				}
+			if err != nil {
+				return nil, "", fmt.Errorf("could not process %v: %v", name, err)
+			}
+			container, err := setBodyValue(jsonValues, splitBodyName(name), value)
+			if err != nil {
+				return nil, "", fmt.Errorf("could not process %v: %v", name, err)
+			}
+			jsonValues = container.(map[string]any)
+		}
+		if path, ok := findMissingItem(jsonValues, ""); ok {
+			return nil, "", fmt.Errorf("could not process %v: missing value, array items can't be skipped", path)
+		}
+		b, err := json.Marshal(jsonValues)
+
+		return b, op.BodyFormat, err
+	}
+	for name := range bodyValues {
+		if jsonName, ok := rawJSONName(name); ok {
+			return nil, "", fmt.Errorf("could not process %v: raw JSON values are only supported for JSON bodies", jsonName)
+		}
+	}
+	if op.BodyFormat == "application/x-www-form-urlencoded" {
+		if rawBody != nil {
+			formValues, err := url.ParseQuery(string(rawBody))
+			if err != nil {
+				return nil, "", fmt.Errorf("could not merge body values: %w", err)
+			}
+			for name, value := range bodyValues {
+				formValues[name] = value
+			}
+			bodyValues = formValues
+		}
+		return []byte(bodyValues.Encode()), op.BodyFormat, nil
+	} else if op.BodyFormat == "multipart/form-data" {
+		if rawBody != nil {
+			return nil, "", errors.New("could not merge body values: unsupported for multipart/form-data")
+		}
+		return op.multipartBody(bodyValues)
+	} else {
+		return nil, "", fmt.Errorf("unsupported body format %v", op.BodyFormat)
+	}
+}
+
+// rawJSONName returns the parameter name for the given raw JSON value name.
+//
+// Raw JSON values are specified using ":=" instead of "=" (e.g. tags:=[]),
+// so their names end with a colon once the body string is parsed.
+func rawJSONName(name string) (string, bool) {
+	return strings.CutSuffix(name, ":")
+}
 
-				if _, ok := temp[subname].(map[string]any); !ok {
-					temp[subname] = make(map[string]any)
+// decodeRawJSON decodes the given raw JSON value.
+func decodeRawJSON(str string) (any, error) {
+	// Numbers are decoded as json.Number to be re-encoded as-is.
+	d := json.NewDecoder(strings.NewReader(str))
+	d.UseNumber()
+	var value any
+	if err := d.Decode(&value); err != nil || d.More() {
+		return nil, fmt.Errorf("%q is not valid JSON", str)
+	}
+
+	return value, nil
+}
+
+// multipartBody converts the given body values into a multipart/form-data body.
+//
+// Values starting with "@" are treated as file paths, with the file contents
+// being attached instead, unless the spec defines the field as non-binary.
+// A leading "@@" is sent as a literal "@". The content type of each part is
+// taken from the spec's encoding object, falling back to the file extension.
+func (op Operation) multipartBody(bodyValues url.Values) ([]byte, string, error) {
+	names := make([]string, 0, len(bodyValues))
+	for name := range bodyValues {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var encoding map[string]string
+	if body, ok := op.body(); ok {
+		encoding = body.Encoding
+	}
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	for _, name := range names {
+		for _, value := range bodyValues[name] {
+			h := make(textproto.MIMEHeader)
+			filename, isFile := strings.CutPrefix(value, "@")
+			if escaped, ok := strings.CutPrefix(filename, "@"); ok {
+				value, isFile = "@"+escaped, false
+			} else if bodyParam, ok := op.bodyParameter(name); ok && bodyParam.Format != "binary" && bodyParam.Format != "byte" {
+				isFile = false
+			}
+			if isFile {
+				b, err := os.ReadFile(filename)
+				if err != nil {
+					return nil, "", fmt.Errorf("could not process %v: %w", name, err)
+				}
+				h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(name), quoteEscaper.Replace(filepath.Base(filename))))
+				h.Set("Content-Type", partContentType(encoding[name], filename))
+				value = string(b)
+			} else {
+				h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(name)))
+				if contentType := encoding[name]; contentType != "" && !strings.ContainsAny(contentType, ",*") {
+					h.Set("Content-Type", contentType)
 				}
-				temp = temp[subname].(map[string]any)
 			}
-			delete(jsonValues, name)
+			part, err := w.CreatePart(h)
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err := part.Write([]byte(value)); err != nil {
+				return nil, "", err
+			}
 		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
 
-		return json.Marshal(jsonValues)
-	} else if op.BodyFormat == "application/x-www-form-urlencoded" {
-		return []byte(bodyValues.Encode()), nil
-	} else {
-		return nil, fmt.Errorf("unsupported body format %v", op.BodyFormat)
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// body returns the selected body format.
+func (op Operation) body() (Body, bool) {
+	for _, body := range op.Bodies {
+		if body.Format == op.BodyFormat {
+			return body, true
+		}
+	}
+	return Body{}, false
+}
+
+// quoteEscaper escapes quotes and backslashes in multipart headers.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// partContentType determines the content type of an attached file.
+//
+// The encoding content type can be a list of types, with wildcards (e.g. "image/png, image/*"),
+// in which case the type matching the file extension is used.
+func partContentType(encodingContentType string, filename string) string {
+	extContentType := mime.TypeByExtension(filepath.Ext(filename))
+	if extContentType == "" {
+		extContentType = "application/octet-stream"
+	}
+	if encodingContentType == "" {
+		return extContentType
+	}
+	extMediaType, _, _ := mime.ParseMediaType(extContentType)
+	contentTypes := strings.Split(encodingContentType, ",")
+	for _, contentType := range contentTypes {
+		contentType = strings.TrimSpace(contentType)
+		if contentType == extMediaType || contentType == "*/*" {
+			return extContentType
+		}
+		if prefix, ok := strings.CutSuffix(contentType, "*"); ok && strings.HasPrefix(extMediaType, prefix) {
+			return extContentType
+		}
 	}
+	firstContentType := strings.TrimSpace(contentTypes[0])
+	if strings.Contains(firstContentType, "*") {
+		return extContentType
+	}
+
+	return firstContentType
+}
+
+// Body represents a supported request body format, with its parameters.
+type Body struct {
+	Format     string
+	Parameters ParameterList
+	Variants   []BodyVariant
+	// Encoding contains the content types of individual multipart
+	// body parameters, keyed by parameter name.
+	Encoding map[string]string
+}
+
+// BodyVariant represents one of the alternative body schemas (oneOf/anyOf).
+type BodyVariant struct {
+	Name        string
+	Description string
+	Parameters  ParameterList
+	// Discriminator is the name of the body parameter whose value
+	// identifies the variant. The expected value is the variant name.
+	Discriminator string
+}
+
+// Response represents a possible operation response.
+type Response struct {
+	// StatusCode is the status code (200), range (4XX), or "default".
+	StatusCode  string
+	Description string
+	Formats     []string
+	// Type is the schema type, e.g. object or []object.
+	Type string
+	// Fields are the top-level schema properties, for object schemas
+	// and arrays of objects.
+	Fields  []string
+	Example string
+}
+
+// castBodyValue casts the given body value using the matching body parameter.
+//
+// Non-defined parameters are passed through as strings.
+func (op Operation) castBodyValue(name string, value string) (any, error) {
+	if bodyParam, ok := op.bodyParameter(name); ok {
+		return bodyParam.CastString(value)
+	}
+
+	return value, nil
+}
+
+// bodyParameter returns the body parameter matching the given value name.
+//
+// Array indexes are ignored when matching, e.g. items[0].sku matches items[].sku.
+// Individual array items (e.g. tags[0]) match the array parameter, with the item type.
+func (op Operation) bodyParameter(name string) (Parameter, bool) {
+	paramName := bodyIndexPattern.ReplaceAllString(name, "[]")
+	if bodyParam, ok := op.Parameters.Body.ByName(paramName); ok {
+		return bodyParam, true
+	}
+	if arrayName, ok := strings.CutSuffix(paramName, "[]"); ok {
+		if bodyParam, ok := op.Parameters.Body.ByName(arrayName); ok && strings.HasPrefix(bodyParam.Type, "[]") {
+			bodyParam.Type = bodyParam.Type[2:]
+			return bodyParam, true
+		}
+	}
+
+	return Parameter{}, false
+}
+
+// bodyIndexPattern matches array indexes in body parameter names, e.g. items[0].sku.
+var bodyIndexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// splitBodyName splits a body parameter name into object keys and array indexes.
+//
+// For example, "items[0].sku" becomes ["items", 0, "sku"].
+// Brackets that don't contain an index are considered a part of the key.
+func splitBodyName(name string) []any {
+	parts := make([]any, 0, 4)
+	for _, key := range strings.Split(name, ".") {
+		var indexes []any
+		for {
+			loc := bodyIndexPattern.FindStringIndex(key)
+			if loc == nil || loc[1] != len(key) {
+				break
+			}
+			index, err := strconv.Atoi(key[loc[0]+1 : loc[1]-1])
+			if err != nil {
+				// Out of range, rejected by setBodyValue.
+				index = math.MaxInt
+			}
+			indexes = append([]any{index}, indexes...)
+			key = key[:loc[0]]
+		}
+		parts = append(parts, key)
+		parts = append(parts, indexes...)
+	}
+
+	return parts
+}
+
+// maxBodyIndexGap is the maximum number of array items that an index can skip.
+//
+// Skipped items must be given by other values (e.g. items[1] before items[0]),
+// so larger gaps are always a mistake, and would allocate huge arrays.
+const maxBodyIndexGap = 1000
+
+// missingItem is a placeholder for array items skipped by an index,
+// which must be replaced by the time all body values are set.
+type missingItem struct{}
+
+// setBodyValue sets a value at the given path, creating any missing objects and arrays.
+//
+// Returns the modified container, which is replaced if it is not of the expected type.
+func setBodyValue(container any, path []any, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch key := path[0].(type) {
+	case string:
+		m, ok := container.(map[string]any)
+		if !ok {
+			m = make(map[string]any)
+		}
+		v, err := setBodyValue(m[key], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+		return m, nil
+	case int:
+		s, _ := container.([]any)
+		if key-len(s) > maxBodyIndexGap {
+			return nil, fmt.Errorf("array index too large, at most %v items can be skipped", maxBodyIndexGap)
+		}
+		for len(s) <= key {
+			s = append(s, missingItem{})
+		}
+		v, err := setBodyValue(s[key], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		s[key] = v
+		return s, nil
+	}
+
+	return container, nil
+}
+
+// findMissingItem finds the first array item that was skipped, returning its path.
+func findMissingItem(value any, path string) (string, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if itemPath, ok := findMissingItem(v[key], keyPath); ok {
+				return itemPath, true
+			}
+		}
+	case []any:
+		for i, item := range v {
+			itemPath := fmt.Sprintf("%v[%d]", path, i)
+			if _, ok := item.(missingItem); ok {
+				return itemPath, true
+			}
+			if itemPath, ok := findMissingItem(item, itemPath); ok {
+				return itemPath, true
+			}
+		}
+	}
+
+	return "", false
 }
 
 // Parameters represents the operation's parameters.
 type Parameters struct {
 	Header ParameterList
+	Cookie ParameterList
 	Path   ParameterList
 	Query  ParameterList
 	Body   ParameterList
@@ -213,6 +953,8 @@ func (ps *Parameters) Add(params ...Parameter) {
 		switch p.In {
 		case "header":
 			ps.Header = append(ps.Header, p)
+		case "cookie":
+			ps.Cookie = append(ps.Cookie, p)
 		case "path":
 			ps.Path = append(ps.Path, p)
 		case "query":
@@ -241,13 +983,35 @@ type Parameter struct {
 	In          string
 	Name        string
 	Description string
-	Style       string
-	Type        string
-	Enum        []string
-	Example     string
-	Default     string
-	Deprecated  bool
-	Required    bool
+	// Style and Explode determine how array and object values are serialized.
+	// Defaults to "simple" for path and header parameters, "form" for query parameters.
+	Style         string
+	Explode       bool
+	AllowReserved bool
+	Type          string
+	// Format is the schema format (e.g. date-time, uuid), used for casting and validation.
+	Format      string
+	Constraints Constraints
+	// Nullable indicates whether the parameter accepts a null value.
+	Nullable   bool
+	Enum       []string
+	Example    string
+	Default    string
+	Deprecated bool
+	Required   bool
+}
+
+// Constraints represent the schema constraints on a parameter value.
+//
+// For arrays, the constraints apply to each item.
+type Constraints struct {
+	Minimum          *float64
+	Maximum          *float64
+	ExclusiveMinimum bool
+	ExclusiveMaximum bool
+	MinLength        *int64
+	MaxLength        *int64
+	Pattern          string
 }
 
 // Label returns a human-readable parameter label.
@@ -256,15 +1020,18 @@ func (p Parameter) Label() string {
 	return strings.Title(strcase.ToDelimited(p.Name, ' '))
 }
 
-// Flags returns the formatted parameter flags (deprecated, required).
+// Flags returns the formatted parameter flags (deprecated, required, nullable).
 func (p Parameter) FormattedFlags() string {
-	flags := make([]string, 0, 2)
+	flags := make([]string, 0, 3)
 	if p.Deprecated {
 		flags = append(flags, "deprecated")
 	}
 	if p.Required {
 		flags = append(flags, "required")
 	}
+	if p.Nullable {
+		flags = append(flags, "nullable")
+	}
 	formatted := ""
 	if len(flags) > 0 {
 		formatted = fmt.Sprintf("(%v)", strings.Join(flags, ", "))
@@ -274,49 +1041,299 @@ func (p Parameter) FormattedFlags() string {
 }
 
 // CastString casts the given string to the parameter type.
+//
+// The "null" string is cast to nil for nullable parameters.
+// String formats are taken into account: dates accept relative values such as
+// "now" or "1d" (and dates for date-times), uuids are validated, and byte values
+// starting with "@" are read from the given file and base64 encoded, with "@@"
+// escaping a literal "@". Binary values can't be read from a file, since they
+// would be corrupted by JSON encoding (see multipartBody).
 func (p Parameter) CastString(str string) (any, error) {
+	if p.Nullable && str == "null" {
+		return nil, nil
+	}
 	if strings.HasPrefix(p.Type, "[]") {
 		strs := strings.Split(str, ",")
 		vs := make([]any, 0, len(strs))
 		for _, s := range strs {
-			v, err := parseStr(s, p.Type[2:])
+			v, err := p.parseStr(s, p.Type[2:])
 			if err != nil {
-				return nil, fmt.Errorf("%q is not a valid %v", s, p.Type[2:])
+				return nil, err
 			}
 			vs = append(vs, v)
 		}
 		return vs, nil
 	} else {
-		v, err := parseStr(str, p.Type)
+		return p.parseStr(str, p.Type)
+	}
+}
+
+// ValidateValues validates the given values against the parameter's type, enum, and constraints.
+//
+// Array values are split into items, which are validated individually.
+// Object values are only checked for being in the key=value format.
+func (p Parameter) ValidateValues(values []string) error {
+	if p.Nullable && len(values) == 1 && values[0] == "null" {
+		return nil
+	}
+	items, _, err := p.splitValues(values)
+	if err != nil || p.isObject() {
+		return err
+	}
+	itemParam := p
+	itemParam.Type = strings.TrimPrefix(p.Type, "[]")
+	for _, item := range items {
+		if err := itemParam.validateValue(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateValue validates a single (non-array) value.
+//
+// Body values are validated after their string format is applied (see CastString),
+// since that is the shape in which they are sent.
+func (p Parameter) validateValue(value string) error {
+	if p.In == "body" && (p.Type == "" || p.Type == "string") {
+		var err error
+		if value, err = castFormat(value, p.Format); err != nil {
+			return err
+		}
+	}
+	if len(p.Enum) > 0 && !slices.Contains(p.Enum, value) {
+		return fmt.Errorf("%q is not one of: %v", value, strings.Join(p.Enum, ", "))
+	}
+	c := p.Constraints
+	switch p.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || (p.Type == "integer" && n != float64(int64(n))) {
+			return fmt.Errorf("%q is not a valid %v", value, p.Type)
+		}
+		if c.Minimum != nil && (n < *c.Minimum || (c.ExclusiveMinimum && n == *c.Minimum)) {
+			return fmt.Errorf("%v is less than the minimum of %v", value, formatBound(*c.Minimum, c.ExclusiveMinimum))
+		}
+		if c.Maximum != nil && (n > *c.Maximum || (c.ExclusiveMaximum && n == *c.Maximum)) {
+			return fmt.Errorf("%v is greater than the maximum of %v", value, formatBound(*c.Maximum, c.ExclusiveMaximum))
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a valid boolean", value)
+		}
+	case "", "string":
+		length := int64(utf8.RuneCountInString(value))
+		if c.MinLength != nil && length < *c.MinLength {
+			return fmt.Errorf("%q is shorter than the minimum length of %v", value, *c.MinLength)
+		}
+		if c.MaxLength != nil && length > *c.MaxLength {
+			return fmt.Errorf("%q is longer than the maximum length of %v", value, *c.MaxLength)
+		}
+	}
+	if c.Pattern != "" {
+		// Patterns using unsupported syntax (e.g. lookaheads) are skipped.
+		if re, err := regexp.Compile(c.Pattern); err == nil && !re.MatchString(value) {
+			return fmt.Errorf("%q does not match the pattern %v", value, c.Pattern)
+		}
+	}
+	valid := isValidFormat(value, p.Format)
+	if p.In == "body" && (p.Format == "date" || p.Format == "date-time") {
+		// Body values are converted by castFormat, which also accepts relative dates.
+		_, valid = parseTime(value)
+	}
+	if !valid {
+		return fmt.Errorf("%q is not a valid %v", value, p.Format)
+	}
+
+	return nil
+}
+
+// formatBound formats a minimum or maximum for display.
+func formatBound(bound float64, exclusive bool) string {
+	formatted := strconv.FormatFloat(bound, 'f', -1, 64)
+	if exclusive {
+		formatted += " (exclusive)"
+	}
+
+	return formatted
+}
+
+// uuidPattern matches UUIDs in the canonical format.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// isValidFormat checks whether the given value matches the given format.
+//
+// Unrecognized formats are always considered valid.
+func isValidFormat(value string, format string) bool {
+	switch format {
+	case "int32":
+		_, err := strconv.ParseInt(value, 10, 32)
+		return err == nil
+	case "int64":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		return err == nil && addr.Address == value
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "ipv4":
+		addr, err := netip.ParseAddr(value)
+		return err == nil && addr.Is4()
+	case "ipv6":
+		addr, err := netip.ParseAddr(value)
+		return err == nil && addr.Is6()
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.IsAbs()
+	}
+
+	return true
+}
+
+// parseStr parses the given string into a value of the given type, using the parameter format.
+func (p Parameter) parseStr(str string, newType string) (any, error) {
+	var v any
+	var err error
+	switch newType {
+	case "boolean":
+		v, err = strconv.ParseBool(str)
+	case "integer":
+		bitSize := 64
+		if p.Format == "int32" {
+			newType = "int32"
+			bitSize = 32
+		}
+		v, err = strconv.ParseInt(str, 10, bitSize)
+	case "number":
+		v, err = strconv.ParseFloat(str, 64)
+	case "", "string":
+		s, err := castFormat(str, p.Format)
 		if err != nil {
-			return nil, fmt.Errorf("%q is not a valid %v", str, p.Type)
+			return nil, err
 		}
-		return v, nil
+		return s, nil
+	default:
+		v = str
 	}
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid %v", str, newType)
+	}
+
+	return v, nil
 }
 
-// parseStr invokes the strconv parse function for the given type.
-func parseStr(str string, newType string) (any, error) {
-	if newType == "boolean" {
-		return strconv.ParseBool(str)
-	} else if newType == "integer" {
-		return strconv.ParseInt(str, 10, 64)
-	} else if newType == "number" {
-		return strconv.ParseFloat(str, 64)
+// castFormat converts the given string according to the given string format.
+//
+// Unrecognized formats are returned as-is.
+func castFormat(str string, format string) (string, error) {
+	switch format {
+	case "date", "date-time":
+		layout := time.RFC3339Nano
+		if format == "date" {
+			layout = time.DateOnly
+		}
+		// Valid values are sent as-is, to preserve their precision and offset.
+		if _, err := time.Parse(layout, str); err == nil {
+			return str, nil
+		}
+		t, ok := parseTime(str)
+		if !ok {
+			return "", fmt.Errorf("%q is not a valid %v", str, format)
+		}
+		return t.Format(layout), nil
+	case "uuid":
+		if !uuidPattern.MatchString(str) {
+			return "", fmt.Errorf("%q is not a valid uuid", str)
+		}
+		return strings.ToLower(str), nil
+	case "byte", "binary":
+		filename, ok := strings.CutPrefix(str, "@")
+		if !ok {
+			return str, nil
+		}
+		if escaped, ok := strings.CutPrefix(filename, "@"); ok {
+			return "@" + escaped, nil
+		}
+		if format == "binary" {
+			// JSON can't hold raw bytes, files are attached by multipartBody instead.
+			return "", errors.New("files can only be sent as binary in multipart/form-data bodies")
+		}
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
 	}
+
 	return str, nil
 }
 
+// relativeTimePattern matches relative times, e.g. 1d or -2h.
+//
+// The sign is optional for future times, since "+" is decoded as
+// a space in body strings, unless escaped (%2B).
+var relativeTimePattern = regexp.MustCompile(`^([+-]?\d+)([smhdw])$`)
+
+// parseTime parses the given date or date-time string.
+//
+// Accepts RFC 3339 date-times, dates (YYYY-MM-DD), "now", and times
+// relative to now, in seconds, minutes, hours, days, or weeks (e.g. 1d, -2h).
+// Relative times are in UTC.
+func parseTime(str string) (time.Time, bool) {
+	now := time.Now().UTC().Truncate(time.Second)
+	if str == "now" {
+		return now, true
+	}
+	if m := relativeTimePattern.FindStringSubmatch(str); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, false
+		}
+		switch m[2] {
+		case "s":
+			return now.Add(time.Duration(n) * time.Second), true
+		case "m":
+			return now.Add(time.Duration(n) * time.Minute), true
+		case "h":
+			return now.Add(time.Duration(n) * time.Hour), true
+		case "d":
+			return now.AddDate(0, 0, n), true
+		case "w":
+			return now.AddDate(0, 0, n*7), true
+		}
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
 // RequestValues represent the values used to populate an operation request.
 //
-// Header, query, and body values are added to the request even if they don't
-// have matching parameters, unlike path values, where the parameter is used
+// Header, cookie, query, and body values are added to the request even if they
+// don't have matching parameters, unlike path values, where the parameter is used
 // to determine the name of the placeholder to replace.
+//
+// The raw body is sent verbatim, unless body values are also present, in which
+// case they are merged on top of it (JSON and form-urlencoded bodies only).
 type RequestValues struct {
-	Header http.Header
-	Path   []string
-	Query  url.Values
-	Body   url.Values
+	Header  http.Header
+	Cookie  url.Values
+	Path    []string
+	Query   url.Values
+	Body    url.Values
+	RawBody []byte
 }
 
 // ParseRequestValues parses parameter values from the given strings.
@@ -333,7 +1350,7 @@ func ParseRequestValues(headers []string, pathValues []string, query string, bod
 	if err != nil {
 		return RequestValues{}, fmt.Errorf("parse query: %w", err)
 	}
-	bodyValues, err := url.ParseQuery(body)
+	bodyValues, err := parseBody(body)
 	if err != nil {
 		return RequestValues{}, fmt.Errorf("parse body: %w", err)
 	}
@@ -346,3 +1363,80 @@ func ParseRequestValues(headers []string, pathValues []string, query string, bod
 
 	return values, nil
 }
+
+// parseBody parses the given body string.
+//
+// Works like url.ParseQuery, except that raw JSON values (name:=value) are
+// kept as-is, allowing them to contain characters such as "&", "+", and "%".
+func parseBody(body string) (url.Values, error) {
+	values := url.Values{}
+	for body != "" {
+		eq := strings.IndexByte(body, '=')
+		amp := strings.IndexByte(body, '&')
+		if eq > 0 && (amp == -1 || eq < amp) && body[eq-1] == ':' {
+			// Invalid JSON is parsed as a regular value, and reported once decoded.
+			if n := rawJSONLength(body[eq+1:]); n > 0 {
+				name, err := url.QueryUnescape(body[:eq])
+				if err != nil {
+					return nil, err
+				}
+				values.Add(name, body[eq+1:eq+1+n])
+				body = strings.TrimPrefix(body[eq+1+n:], "&")
+				continue
+			}
+		}
+		var pair string
+		pair, body, _ = strings.Cut(body, "&")
+		if pair == "" {
+			continue
+		}
+		if strings.Contains(pair, ";") {
+			return nil, errors.New("invalid semicolon separator in query")
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return nil, err
+		}
+		values.Add(name, value)
+	}
+
+	return values, nil
+}
+
+// rawJSONLength returns the length of the JSON value at the start of the given string.
+//
+// Returns 0 if the string doesn't start with a valid JSON value followed by "&" or the end.
+func rawJSONLength(s string) int {
+	d := json.NewDecoder(strings.NewReader(s))
+	var value json.RawMessage
+	if err := d.Decode(&value); err != nil {
+		return 0
+	}
+	n := int(d.InputOffset())
+	if n < len(s) && s[n] != '&' {
+		return 0
+	}
+
+	return n
+}
+
+// ParseCookieValues parses cookie values from the given strings.
+//
+// Cookies are expected in the name=value format.
+func ParseCookieValues(cookies []string) (url.Values, error) {
+	cookieValues := make(url.Values, len(cookies))
+	for _, cookie := range cookies {
+		name, value, ok := strings.Cut(cookie, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("parse cookie: could not parse %q", cookie)
+		}
+		cookieValues.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return cookieValues, nil
+}
@@ -4,11 +4,18 @@
 package broom_test
 
 import (
+	"encoding/json"
+	"errors"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bojanz/broom"
 	"github.com/google/go-cmp/cmp"
@@ -38,18 +45,20 @@ func TestOperations_ByID(t *testing.T) {
 
 func TestOperations_ByTag(t *testing.T) {
 	ops := broom.Operations{
-		broom.Operation{ID: "create-product", Tag: "Products"},
-		broom.Operation{ID: "update-product", Tag: "Products"},
-		broom.Operation{ID: "delete-product", Tag: "Products"},
-		broom.Operation{ID: "create-user", Tag: "Users"},
-		broom.Operation{ID: "update-user", Tag: "Users"},
+		broom.Operation{ID: "create-product", Tags: []string{"Products"}},
+		broom.Operation{ID: "update-product", Tags: []string{"Products"}},
+		broom.Operation{ID: "delete-product", Tags: []string{"Products"}},
+		broom.Operation{ID: "create-user", Tags: []string{"Users"}},
+		broom.Operation{ID: "update-user", Tags: []string{"Users"}},
+		broom.Operation{ID: "upload-user-image", Tags: []string{"Users", "Images"}},
+		broom.Operation{ID: "get-status"},
 	}
 
 	gotOps := ops.ByTag("Products")
 	wantOps := broom.Operations{
-		broom.Operation{ID: "create-product", Tag: "Products"},
-		broom.Operation{ID: "update-product", Tag: "Products"},
-		broom.Operation{ID: "delete-product", Tag: "Products"},
+		broom.Operation{ID: "create-product", Tags: []string{"Products"}},
+		broom.Operation{ID: "update-product", Tags: []string{"Products"}},
+		broom.Operation{ID: "delete-product", Tags: []string{"Products"}},
 	}
 	if diff := cmp.Diff(wantOps, gotOps); diff != "" {
 		t.Errorf("product operation mismatch (-want +got):\n%s", diff)
@@ -57,27 +66,62 @@ func TestOperations_ByTag(t *testing.T) {
 
 	gotOps = ops.ByTag("Users")
 	wantOps = broom.Operations{
-		broom.Operation{ID: "create-user", Tag: "Users"},
-		broom.Operation{ID: "update-user", Tag: "Users"},
+		broom.Operation{ID: "create-user", Tags: []string{"Users"}},
+		broom.Operation{ID: "update-user", Tags: []string{"Users"}},
+		broom.Operation{ID: "upload-user-image", Tags: []string{"Users", "Images"}},
 	}
 	if diff := cmp.Diff(wantOps, gotOps); diff != "" {
 		t.Errorf("user operation mismatch (-want +got):\n%s", diff)
 	}
+
+	gotOps = ops.ByTag("Images")
+	wantOps = broom.Operations{
+		broom.Operation{ID: "upload-user-image", Tags: []string{"Users", "Images"}},
+	}
+	if diff := cmp.Diff(wantOps, gotOps); diff != "" {
+		t.Errorf("image operation mismatch (-want +got):\n%s", diff)
+	}
+
+	gotOps = ops.ByTag("")
+	wantOps = broom.Operations{
+		broom.Operation{ID: "get-status"},
+	}
+	if diff := cmp.Diff(wantOps, gotOps); diff != "" {
+		t.Errorf("untagged operation mismatch (-want +got):\n%s", diff)
+	}
 }
 
 func TestOperations_Tags(t *testing.T) {
 	ops := broom.Operations{
-		broom.Operation{ID: "create-product", Tag: "Products"},
-		broom.Operation{ID: "update-product", Tag: "Products"},
-		broom.Operation{ID: "delete-product", Tag: "Products"},
-		broom.Operation{ID: "create-user", Tag: "Users"},
-		broom.Operation{ID: "update-user", Tag: "Users"},
+		broom.Operation{ID: "create-product", Tags: []string{"Products"}},
+		broom.Operation{ID: "update-product", Tags: []string{"Products"}},
+		broom.Operation{ID: "delete-product", Tags: []string{"Products"}},
+		broom.Operation{ID: "create-user", Tags: []string{"Users"}},
+		broom.Operation{ID: "update-user", Tags: []string{"Users"}},
+		broom.Operation{ID: "upload-user-image", Tags: []string{"Users", "Images"}},
 	}
 
-	wantTags := []string{"Products", "Users"}
-	gotTags := ops.Tags()
-	if !cmp.Equal(gotTags, wantTags) {
-		t.Errorf("got %v, want %v", gotTags, wantTags)
+	// No spec tags.
+	wantTags := []broom.Tag{{Name: "Images"}, {Name: "Products"}, {Name: "Users"}}
+	gotTags := ops.Tags(nil)
+	if diff := cmp.Diff(wantTags, gotTags); diff != "" {
+		t.Errorf("tag mismatch (-want +got):\n%s", diff)
+	}
+
+	// Spec tags, including an unused one.
+	specTags := []broom.Tag{
+		{Name: "Users", Description: "Manage users."},
+		{Name: "Orders", Description: "Manage orders."},
+		{Name: "Products", Description: "Manage products."},
+	}
+	wantTags = []broom.Tag{
+		{Name: "Users", Description: "Manage users."},
+		{Name: "Products", Description: "Manage products."},
+		{Name: "Images"},
+	}
+	gotTags = ops.Tags(specTags)
+	if diff := cmp.Diff(wantTags, gotTags); diff != "" {
+		t.Errorf("tag mismatch (-want +got):\n%s", diff)
 	}
 }
 
@@ -102,6 +146,128 @@ func TestOperation_SummaryWithFlags(t *testing.T) {
 	}
 }
 
+func TestOperation_IsPublic(t *testing.T) {
+	tests := []struct {
+		security []broom.SecurityRequirement
+		want     bool
+	}{
+		{nil, false},
+		{[]broom.SecurityRequirement{{"BearerAuth"}}, false},
+		{[]broom.SecurityRequirement{{}}, true},
+		{[]broom.SecurityRequirement{{"BearerAuth"}, {}}, true},
+	}
+	for _, tt := range tests {
+		op := broom.Operation{Security: tt.security}
+		got := op.IsPublic()
+		if got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.security, got, tt.want)
+		}
+	}
+}
+
+func TestOperation_WithBodyFormat(t *testing.T) {
+	op := broom.Operation{ID: "create-user"}
+	_, err := op.WithBodyFormat("application/json")
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != "operation create-user has no body" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	jsonBody := broom.Body{
+		Format: "application/json",
+		Parameters: broom.ParameterList{
+			broom.Parameter{In: "body", Name: "username", Type: "string"},
+			broom.Parameter{In: "body", Name: "roles", Type: "[]string"},
+		},
+	}
+	formBody := broom.Body{
+		Format: "application/x-www-form-urlencoded",
+		Parameters: broom.ParameterList{
+			broom.Parameter{In: "body", Name: "username", Type: "string"},
+		},
+	}
+	op.Bodies = []broom.Body{jsonBody, formBody}
+	_, err = op.WithBodyFormat("application/xml")
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != `unsupported body format "application/xml", must be one of: application/json, application/x-www-form-urlencoded` {
+		t.Errorf("unexpected error %v", err)
+	}
+	_, err = op.WithBodyFormat("application")
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != `ambiguous body format "application", must be one of: application/json, application/x-www-form-urlencoded` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	for _, format := range []string{"application/x-www-form-urlencoded", "form"} {
+		formOp, err := op.WithBodyFormat(format)
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+		if formOp.BodyFormat != formBody.Format {
+			t.Errorf("got %v, want %v", formOp.BodyFormat, formBody.Format)
+		}
+		if diff := cmp.Diff(formBody.Parameters, formOp.Parameters.Body); diff != "" {
+			t.Errorf("body parameter mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestOperation_WithBodyVariant(t *testing.T) {
+	op := broom.Operation{ID: "create-payment"}
+	_, err := op.WithBodyVariant("card")
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != "operation create-payment has no body variants" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	op.Parameters.Add(
+		broom.Parameter{In: "body", Name: "amount"},
+		broom.Parameter{In: "body", Name: "currency_code"},
+	)
+	op.BodyVariants = []broom.BodyVariant{
+		{
+			Name: "card",
+			Parameters: broom.ParameterList{
+				broom.Parameter{In: "body", Name: "amount", Required: true},
+				broom.Parameter{In: "body", Name: "card_number", Required: true},
+			},
+		},
+		{
+			Name: "bank",
+			Parameters: broom.ParameterList{
+				broom.Parameter{In: "body", Name: "iban"},
+			},
+		},
+	}
+	_, err = op.WithBodyVariant("cash")
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != `unknown body variant "cash", must be one of: card, bank` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	cardOp, err := op.WithBodyVariant("card")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	wantBody := broom.ParameterList{
+		broom.Parameter{In: "body", Name: "amount", Required: true},
+		broom.Parameter{In: "body", Name: "currency_code"},
+		broom.Parameter{In: "body", Name: "card_number", Required: true},
+	}
+	if diff := cmp.Diff(wantBody, cardOp.Parameters.Body); diff != "" {
+		t.Errorf("body parameter mismatch (-want +got):\n%s", diff)
+	}
+	// Confirm that the original operation was not modified.
+	if len(op.Parameters.Body) != 2 || op.Parameters.Body[0].Required {
+		t.Errorf("unexpected modification of the original operation: %v", op.Parameters.Body)
+	}
+}
+
 func TestOperation_Validate(t *testing.T) {
 	// Missing path parameter.
 	op := broom.Operation{Path: "/users/{userId}"}
@@ -112,7 +278,74 @@ func TestOperation_Validate(t *testing.T) {
 	err := op.Validate(broom.RequestValues{})
 	if err == nil {
 		t.Error("expected error, got nil")
-	} else if err.Error() != "too few path parameters: got 0, want 1" {
+	} else if err.Error() != "missing required parameters: userId (path)" {
+		t.Errorf("unexpected error %v", err)
+	}
+	// Request doesn't validate, allowing incomplete requests to be sent on purpose.
+	if _, err = op.Request("https://myapi.io", broom.RequestValues{}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// Missing header, query, and body parameters.
+	maxLimit := 100.0
+	op = broom.Operation{Method: "POST", Path: "/users", BodyFormat: "application/json"}
+	op.Parameters.Add(
+		broom.Parameter{In: "header", Name: "X-Vendor", Required: true},
+		broom.Parameter{In: "query", Name: "country", Required: true},
+		broom.Parameter{In: "query", Name: "sort", Required: true, Default: "name"},
+		broom.Parameter{In: "query", Name: "page"},
+		broom.Parameter{In: "body", Name: "username", Required: true},
+		broom.Parameter{In: "body", Name: "email", Required: true},
+		broom.Parameter{In: "body", Name: "address.city", Required: true},
+		broom.Parameter{In: "body", Name: "billing.city", Required: true},
+		broom.Parameter{In: "body", Name: "items[].sku", Required: true},
+	)
+	values, _ := broom.ParseRequestValues(nil, nil, "page=2", "email=js@domain&address.country=US")
+	err = op.Validate(values)
+	var validationErr *broom.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("got %v, want a *broom.ValidationError", err)
+	}
+	var gotMissing []string
+	for _, p := range validationErr.Missing {
+		gotMissing = append(gotMissing, p.In+" "+p.Name)
+	}
+	wantMissing := []string{"header X-Vendor", "query country", "body username", "body address.city"}
+	if diff := cmp.Diff(wantMissing, gotMissing); diff != "" {
+		t.Errorf("missing parameter mismatch (-want +got):\n%s", diff)
+	}
+	wantErr := "missing required parameters: X-Vendor (header), country (query), username (body), address.city (body)"
+	if err.Error() != wantErr {
+		t.Errorf("got %v, want %v", err, wantErr)
+	}
+
+	// Invalid query and body values.
+	op.Parameters.Add(
+		broom.Parameter{In: "query", Name: "limit", Type: "integer", Constraints: broom.Constraints{Maximum: &maxLimit}},
+		broom.Parameter{In: "body", Name: "tags", Type: "[]string", Enum: []string{"new", "sale"}},
+	)
+	values, _ = broom.ParseRequestValues([]string{"X-Vendor: Test"}, nil, "country=US&limit=500", "username=jsmith&email=js@domain&tags=new,old")
+	if err = op.Validate(values); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	err = op.ValidateValues(values)
+	wantErr = `invalid limit (query): 500 is greater than the maximum of 100; invalid tags (body): "old" is not one of: new, sale`
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != wantErr {
+		t.Errorf("got %v, want %v", err, wantErr)
+	}
+
+	// Body parameters provided as raw JSON values.
+	values, _ = broom.ParseRequestValues([]string{"X-Vendor: Test"}, nil, "country=US", "username:=null&email=js@domain")
+	if err = op.Validate(values); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// Body parameters provided via the raw body.
+	values, _ = broom.ParseRequestValues([]string{"X-Vendor: Test"}, nil, "country=US", "email=js@domain")
+	values.RawBody = []byte(`{"username": "jsmith", "address": {"city": "Boston"}}`)
+	if err = op.Validate(values); err != nil {
 		t.Errorf("unexpected error %v", err)
 	}
 }
@@ -229,6 +462,95 @@ func TestOperation_Request(t *testing.T) {
 	if ua := req.Header.Get("User-Agent"); !strings.HasPrefix(ua, "broom") {
 		t.Errorf("unexpected user agent %v", req.Header.Get("User-Agent"))
 	}
+
+	// Confirm that the expected cookies are set.
+	values, _ = broom.ParseRequestValues(nil, nil, "", "")
+	values.Cookie, _ = broom.ParseCookieValues([]string{"session=abc123", "locale=en"})
+	req, err = op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if got := req.Header.Get("Cookie"); got != "locale=en; session=abc123" {
+		t.Errorf(`got %v, want "locale=en; session=abc123"`, got)
+	}
+}
+
+func TestOperation_RequestWithStyles(t *testing.T) {
+	tests := []struct {
+		param   broom.Parameter
+		value   string
+		wantURL string
+	}{
+		// Path parameters.
+		{broom.Parameter{In: "path", Name: "id"}, "a/b c", "https://myapi.io/items/a%2Fb%20c"},
+		{broom.Parameter{In: "path", Name: "id", Type: "[]string"}, "3,4,5", "https://myapi.io/items/3,4,5"},
+		{broom.Parameter{In: "path", Name: "id", Type: "object"}, "R=100,G=200", "https://myapi.io/items/R,100,G,200"},
+		{broom.Parameter{In: "path", Name: "id", Type: "object", Explode: true}, "R=100,G=200", "https://myapi.io/items/R=100,G=200"},
+		{broom.Parameter{In: "path", Name: "id", Style: "label"}, "5", "https://myapi.io/items/.5"},
+		{broom.Parameter{In: "path", Name: "id", Style: "label", Type: "[]string"}, "3,4,5", "https://myapi.io/items/.3,4,5"},
+		{broom.Parameter{In: "path", Name: "id", Style: "label", Type: "[]string", Explode: true}, "3,4,5", "https://myapi.io/items/.3.4.5"},
+		{broom.Parameter{In: "path", Name: "id", Style: "label", Type: "object", Explode: true}, "R=100,G=200", "https://myapi.io/items/.R=100.G=200"},
+		{broom.Parameter{In: "path", Name: "id", Style: "matrix"}, "5", "https://myapi.io/items/;id=5"},
+		{broom.Parameter{In: "path", Name: "id", Style: "matrix", Type: "[]string"}, "3,4,5", "https://myapi.io/items/;id=3,4,5"},
+		{broom.Parameter{In: "path", Name: "id", Style: "matrix", Type: "[]string", Explode: true}, "3,4,5", "https://myapi.io/items/;id=3;id=4;id=5"},
+		{broom.Parameter{In: "path", Name: "id", Style: "matrix", Type: "object"}, "R=100,G=200", "https://myapi.io/items/;id=R,100,G,200"},
+		{broom.Parameter{In: "path", Name: "id", Style: "matrix", Type: "object", Explode: true}, "R=100,G=200", "https://myapi.io/items/;R=100;G=200"},
+
+		// Query parameters.
+		{broom.Parameter{In: "query", Name: "color", Style: "form"}, "blue black", "https://myapi.io/items?color=blue+black"},
+		{broom.Parameter{In: "query", Name: "color", Style: "form", Type: "[]string"}, "blue,black", "https://myapi.io/items?color=blue,black"},
+		{broom.Parameter{In: "query", Name: "color", Style: "form", Type: "[]string", Explode: true}, "blue,black", "https://myapi.io/items?color=blue&color=black"},
+		{broom.Parameter{In: "query", Name: "color", Style: "form", Type: "object"}, "R=100,G=200", "https://myapi.io/items?color=R,100,G,200"},
+		{broom.Parameter{In: "query", Name: "color", Style: "form", Type: "object", Explode: true}, "R=100,G=200", "https://myapi.io/items?R=100&G=200"},
+		{broom.Parameter{In: "query", Name: "color", Style: "spaceDelimited", Type: "[]string"}, "blue,black", "https://myapi.io/items?color=blue%20black"},
+		{broom.Parameter{In: "query", Name: "color", Style: "pipeDelimited", Type: "[]string"}, "blue,black", "https://myapi.io/items?color=blue|black"},
+		{broom.Parameter{In: "query", Name: "color", Style: "deepObject", Type: "object", Explode: true}, "R=100,G=200", "https://myapi.io/items?color[R]=100&color[G]=200"},
+		{broom.Parameter{In: "query", Name: "redirect", Style: "form"}, "https://a.io/?b=c", "https://myapi.io/items?redirect=https%3A%2F%2Fa.io%2F%3Fb%3Dc"},
+		{broom.Parameter{In: "query", Name: "redirect", Style: "form", AllowReserved: true}, "https://a.io/?b=c#d", "https://myapi.io/items?redirect=https://a.io/?b=c%23d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantURL, func(t *testing.T) {
+			op := broom.Operation{Method: "GET", Path: "/items"}
+			var pathValues []string
+			query := ""
+			if tt.param.In == "path" {
+				op.Path = "/items/{id}"
+				pathValues = []string{tt.value}
+			} else {
+				query = url.Values{tt.param.Name: []string{tt.value}}.Encode()
+			}
+			op.Parameters.Add(tt.param)
+			values, _ := broom.ParseRequestValues(nil, pathValues, query, "")
+			req, err := op.Request("https://myapi.io", values)
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if req.URL.String() != tt.wantURL {
+				t.Errorf("got %v, want %v", req.URL.String(), tt.wantURL)
+			}
+		})
+	}
+
+	// Header parameters.
+	op := broom.Operation{Method: "GET", Path: "/items"}
+	op.Parameters.Add(broom.Parameter{In: "header", Name: "X-Color", Style: "simple", Type: "object"})
+	values, _ := broom.ParseRequestValues([]string{"X-Color: R=100,G=200"}, nil, "", "")
+	req, err := op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if got := req.Header.Get("X-Color"); got != "R,100,G,200" {
+		t.Errorf("got %v, want R,100,G,200", got)
+	}
+
+	// Invalid object value.
+	values, _ = broom.ParseRequestValues([]string{"X-Color: red"}, nil, "", "")
+	_, err = op.Request("https://myapi.io", values)
+	if err == nil {
+		t.Error("expected Request() to return an error")
+	} else if err.Error() != `X-Color: "red" is not a valid object, expected key=value pairs` {
+		t.Errorf("unexpected error %v", err)
+	}
 }
 
 func TestOperation_RequestWithBody(t *testing.T) {
@@ -400,6 +722,350 @@ func TestOperation_RequestWithBody(t *testing.T) {
 	}
 }
 
+func TestOperation_RequestWithRawJSONValues(t *testing.T) {
+	op := broom.Operation{
+		Method:     "PATCH",
+		Path:       "/users/{userId}",
+		BodyFormat: "application/json",
+	}
+	op.Parameters.Add(
+		broom.Parameter{
+			In:   "path",
+			Name: "userId",
+		},
+		broom.Parameter{
+			In:       "body",
+			Name:     "nickname",
+			Type:     "string",
+			Nullable: true,
+		},
+		broom.Parameter{
+			In:   "body",
+			Name: "roles",
+			Type: "[]string",
+		},
+		broom.Parameter{
+			In:   "body",
+			Name: "bio",
+			Type: "string",
+		},
+	)
+	values, _ := broom.ParseRequestValues(nil, []string{"jsmith"}, "", `nickname=null&bio=null&roles:=[]&meta:={"source":"cli","retries":2}&address.zip:=null`)
+	req, err := op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	b, _ := io.ReadAll(req.Body)
+	got := string(b)
+	want := `{"address":{"zip":null},"bio":"null","meta":{"retries":2,"source":"cli"},"nickname":null,"roles":[]}`
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Raw JSON values are not URL decoded.
+	values, _ = broom.ParseRequestValues(nil, []string{"jsmith"}, "", `meta:={"note":"x+y & 100%"}&bio=a%26b`)
+	req, err = op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	b, _ = io.ReadAll(req.Body)
+	got = string(b)
+	// The JSON encoder escapes "&" as \u0026.
+	want = `{"bio":"a\u0026b","meta":{"note":"x+y \u0026 100%"}}`
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Invalid JSON.
+	values, _ = broom.ParseRequestValues(nil, []string{"jsmith"}, "", "roles:=[admin]")
+	_, err = op.Request("https://myapi.io", values)
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != `could not process roles: "[admin]" is not valid JSON` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// Raw JSON values in a non-JSON body.
+	op.BodyFormat = "application/x-www-form-urlencoded"
+	values, _ = broom.ParseRequestValues(nil, []string{"jsmith"}, "", "roles:=[]")
+	_, err = op.Request("https://myapi.io", values)
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != "could not process roles: raw JSON values are only supported for JSON bodies" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestOperation_RequestWithFormattedValues(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "coupon.png"), []byte("PNG"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	op := broom.Operation{
+		Method:     "POST",
+		Path:       "/coupons",
+		BodyFormat: "application/json",
+	}
+	op.Parameters.Add(
+		broom.Parameter{
+			In:     "body",
+			Name:   "expires_at",
+			Type:   "string",
+			Format: "date-time",
+		},
+		broom.Parameter{
+			In:     "body",
+			Name:   "image",
+			Type:   "string",
+			Format: "byte",
+		},
+	)
+	values, err := broom.ParseRequestValues(nil, nil, "", "expires_at=30d&image=@"+filepath.Join(dir, "coupon.png"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	req, err := op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var got struct {
+		ExpiresAt time.Time `json:"expires_at"`
+		Image     string    `json:"image"`
+	}
+	b, _ := io.ReadAll(req.Body)
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	want := time.Now().AddDate(0, 0, 30)
+	if got.ExpiresAt.Sub(want).Abs() > time.Minute {
+		t.Errorf("got %v, want %v", got.ExpiresAt, want)
+	}
+	if got.Image != "UE5H" {
+		t.Errorf("got %v, want UE5H", got.Image)
+	}
+}
+
+func TestOperation_RequestWithArrayBody(t *testing.T) {
+	op := broom.Operation{
+		Method:     "POST",
+		Path:       "/orders",
+		BodyFormat: "application/json",
+	}
+	op.Parameters.Add(
+		broom.Parameter{
+			In:   "body",
+			Name: "items[].sku",
+			Type: "string",
+		},
+		broom.Parameter{
+			In:   "body",
+			Name: "items[].quantity",
+			Type: "integer",
+		},
+		broom.Parameter{
+			In:   "body",
+			Name: "lucky_numbers",
+			Type: "[]integer",
+		},
+	)
+	// Invalid integer in an item.
+	values, _ := broom.ParseRequestValues(nil, nil, "", "items[0].sku=A&items[0].quantity=two")
+	_, err := op.Request("https://myapi.io", values)
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != `could not process items[0].quantity: "two" is not a valid integer` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// Skipped array items.
+	values, _ = broom.ParseRequestValues(nil, nil, "", "items[0].sku=A&items[2].sku=C")
+	_, err = op.Request("https://myapi.io", values)
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != `could not process items[1]: missing value, array items can't be skipped` {
+		t.Errorf("unexpected error %v", err)
+	}
+	values, _ = broom.ParseRequestValues(nil, nil, "", "items[10000000000].sku=A")
+	_, err = op.Request("https://myapi.io", values)
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != `could not process items[10000000000].sku: array index too large, at most 1000 items can be skipped` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// Valid data.
+	values, _ = broom.ParseRequestValues(nil, nil, "", "items[0].sku=A&items[0].quantity=2&items[1].sku=B&lucky_numbers[1]=8&lucky_numbers[0]=4&filter[owner]=me")
+	req, err := op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	b, _ := io.ReadAll(req.Body)
+	got := string(b)
+	want := `{"filter[owner]":"me","items":[{"quantity":2,"sku":"A"},{"sku":"B"}],"lucky_numbers":[4,8]}`
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOperation_RequestWithMultipartBody(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "shirt.png"), []byte("PNG"), 0644)
+	os.WriteFile(filepath.Join(dir, "shirt.data"), []byte("DATA"), 0644)
+	op := broom.Operation{
+		Method:     "PUT",
+		Path:       "/image",
+		BodyFormat: "multipart/form-data",
+		Bodies: []broom.Body{
+			{
+				Format: "multipart/form-data",
+				Encoding: map[string]string{
+					"image":     "image/png, image/jpeg",
+					"thumbnail": "image/jpeg",
+					"meta":      "application/json",
+				},
+			},
+		},
+	}
+	op.Parameters.Add(
+		broom.Parameter{In: "body", Name: "image", Type: "string", Format: "binary"},
+		broom.Parameter{In: "body", Name: "handle", Type: "string"},
+	)
+
+	// Missing file.
+	values, _ := broom.ParseRequestValues(nil, nil, "", "image=@"+filepath.Join(dir, "missing.png"))
+	_, err := op.Request("https://myapi.io", values)
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if !strings.HasPrefix(err.Error(), "could not process image: open ") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// Valid data.
+	body := url.Values{}
+	body.Set("alt", "A shirt")
+	body.Set("meta", `{"color":"red"}`)
+	body.Set("image", "@"+filepath.Join(dir, "shirt.png"))
+	body.Set("thumbnail", "@"+filepath.Join(dir, "shirt.png"))
+	body.Set("attachment", "@"+filepath.Join(dir, "shirt.data"))
+	// Values of non-binary fields are not treated as files.
+	body.Set("handle", "@shirt")
+	body.Set("note", "@@shirt")
+	values, _ = broom.ParseRequestValues(nil, nil, "", body.Encode())
+	req, err := op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		t.Errorf("got %v, want multipart/form-data", mediaType)
+	}
+	type part struct {
+		Name        string
+		Filename    string
+		ContentType string
+		Content     string
+	}
+	var gotParts []part
+	r := multipart.NewReader(req.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		b, _ := io.ReadAll(p)
+		gotParts = append(gotParts, part{p.FormName(), p.FileName(), p.Header.Get("Content-Type"), string(b)})
+	}
+	wantParts := []part{
+		{"alt", "", "", "A shirt"},
+		{"attachment", "shirt.data", "application/octet-stream", "DATA"},
+		{"handle", "", "", "@shirt"},
+		{"image", "shirt.png", "image/png", "PNG"},
+		{"meta", "", "application/json", `{"color":"red"}`},
+		{"note", "", "", "@shirt"},
+		{"thumbnail", "shirt.png", "image/jpeg", "PNG"},
+	}
+	if diff := cmp.Diff(wantParts, gotParts); diff != "" {
+		t.Errorf("part mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOperation_RequestWithRawBody(t *testing.T) {
+	// Raw body, sent verbatim.
+	op := broom.Operation{
+		Method:     "POST",
+		Path:       "/users",
+		BodyFormat: "application/xml",
+	}
+	values := broom.RequestValues{RawBody: []byte("<user><name>jsmith</name></user>")}
+	req, err := op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	b, _ := io.ReadAll(req.Body)
+	if string(b) != string(values.RawBody) {
+		t.Errorf("got %v, want %v", string(b), string(values.RawBody))
+	}
+	if req.Header.Get("Content-Type") != op.BodyFormat {
+		t.Errorf("got %v, want %v", req.Header.Get("Content-Type"), op.BodyFormat)
+	}
+
+	// Raw JSON body with merged values.
+	op = broom.Operation{
+		Method:     "POST",
+		Path:       "/users",
+		BodyFormat: "application/json",
+	}
+	op.Parameters.Add(
+		broom.Parameter{
+			In:   "body",
+			Name: "infra.storage",
+			Type: "integer",
+		},
+	)
+	values, _ = broom.ParseRequestValues(nil, nil, "", "username=jsmith&infra.storage=20480")
+	values.RawBody = []byte(`{"username": "john", "id": 12345678901234567890, "meta": null, "infra": {"vcpu": 0.5}}`)
+	req, err = op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	b, _ = io.ReadAll(req.Body)
+	got := string(b)
+	want := `{"id":12345678901234567890,"infra":{"storage":20480,"vcpu":0.5},"meta":null,"username":"jsmith"}`
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Raw JSON body that is not an object.
+	values.RawBody = []byte(`[1, 2]`)
+	_, err = op.Request("https://myapi.io", values)
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if !strings.HasPrefix(err.Error(), "could not merge body values: raw body is not a JSON object") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// Raw form body with merged values.
+	op = broom.Operation{
+		Method:     "POST",
+		Path:       "/users",
+		BodyFormat: "application/x-www-form-urlencoded",
+	}
+	values, _ = broom.ParseRequestValues(nil, nil, "", "username=jsmith")
+	values.RawBody = []byte("email=js@domain&username=john")
+	req, err = op.Request("https://myapi.io", values)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	b, _ = io.ReadAll(req.Body)
+	got = string(b)
+	want = "email=js%40domain&username=jsmith"
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func TestParameters_ByName(t *testing.T) {
 	parameters := broom.ParameterList{
 		broom.Parameter{
@@ -429,6 +1095,150 @@ func TestParameters_ByName(t *testing.T) {
 	}
 }
 
+func TestParameter_CastString(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "logo.txt")
+	if err := os.WriteFile(filename, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		param   broom.Parameter
+		str     string
+		want    any
+		wantErr string
+	}{
+		{broom.Parameter{Type: "string"}, "abc", "abc", ""},
+		{broom.Parameter{Type: "boolean"}, "true", true, ""},
+		{broom.Parameter{Type: "boolean"}, "yes", nil, `"yes" is not a valid boolean`},
+		{broom.Parameter{Type: "integer"}, "3000000000", int64(3000000000), ""},
+		{broom.Parameter{Type: "integer", Format: "int32"}, "300", int64(300), ""},
+		{broom.Parameter{Type: "integer", Format: "int32"}, "3000000000", nil, `"3000000000" is not a valid int32`},
+		{broom.Parameter{Type: "number"}, "1.5", 1.5, ""},
+		{broom.Parameter{Type: "[]integer"}, "1,2", []any{int64(1), int64(2)}, ""},
+		{broom.Parameter{Type: "[]integer"}, "1,b", nil, `"b" is not a valid integer`},
+		{broom.Parameter{Type: "[]integer", Nullable: true}, "null", nil, ""},
+		{broom.Parameter{Type: "string"}, "null", "null", ""},
+		{broom.Parameter{Type: "string", Format: "date"}, "2024-01-31", "2024-01-31", ""},
+		{broom.Parameter{Type: "string", Format: "date"}, "2024-01-31T23:00:00Z", "2024-01-31", ""},
+		{broom.Parameter{Type: "string", Format: "date"}, "31/01/2024", nil, `"31/01/2024" is not a valid date`},
+		{broom.Parameter{Type: "string", Format: "date-time"}, "2024-01-31T10:00:00+01:00", "2024-01-31T10:00:00+01:00", ""},
+		{broom.Parameter{Type: "string", Format: "date-time"}, "2024-01-01T10:00:00.123456Z", "2024-01-01T10:00:00.123456Z", ""},
+		{broom.Parameter{Type: "string", Format: "date-time"}, "2024-01-31", "2024-01-31T00:00:00Z", ""},
+		{broom.Parameter{Type: "string", Format: "date-time"}, "tomorrow", nil, `"tomorrow" is not a valid date-time`},
+		{broom.Parameter{Type: "string", Format: "uuid"}, "C3B6A7B2-5F1E-4D2A-9A3E-0F5D3C2B1A09", "c3b6a7b2-5f1e-4d2a-9a3e-0f5d3c2b1a09", ""},
+		{broom.Parameter{Type: "string", Format: "uuid"}, "c3b6a7b2", nil, `"c3b6a7b2" is not a valid uuid`},
+		{broom.Parameter{Type: "string", Format: "byte"}, "aGVsbG8=", "aGVsbG8=", ""},
+		{broom.Parameter{Type: "string", Format: "byte"}, "@" + filename, "aGVsbG8=", ""},
+		{broom.Parameter{Type: "string", Format: "binary"}, "@" + filename, nil, "files can only be sent as binary in multipart/form-data bodies"},
+		{broom.Parameter{Type: "string", Format: "binary"}, "@@hello", "@hello", ""},
+		{broom.Parameter{Type: "[]string", Format: "byte"}, "@" + filename + ",d29ybGQ=", []any{"aGVsbG8=", "d29ybGQ="}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got, err := tt.param.CastString(tt.str)
+			gotErr := ""
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("got error %q, want %q", gotErr, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("value mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// Relative dates.
+	param := broom.Parameter{Type: "string", Format: "date-time"}
+	for str, offset := range map[string]time.Duration{"now": 0, "1d": 24 * time.Hour, "+1d": 24 * time.Hour, "-2h": -2 * time.Hour, "+30m": 30 * time.Minute} {
+		v, err := param.CastString(str)
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+		got, err := time.Parse(time.RFC3339, v.(string))
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+		want := time.Now().Add(offset)
+		if got.Sub(want).Abs() > time.Minute {
+			t.Errorf("%v: got %v, want %v", str, got, want)
+		}
+	}
+	param = broom.Parameter{Type: "string", Format: "date"}
+	got, _ := param.CastString("+1w")
+	want := time.Now().UTC().AddDate(0, 0, 7).Format(time.DateOnly)
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParameter_ValidateValues(t *testing.T) {
+	one := 1.0
+	ten := 10.0
+	minLength := int64(2)
+	maxLength := int64(4)
+	tests := []struct {
+		param   broom.Parameter
+		values  []string
+		wantErr string
+	}{
+		{broom.Parameter{Type: "string"}, []string{"anything"}, ""},
+		{broom.Parameter{Type: "string", Enum: []string{"draft", "published"}}, []string{"draft"}, ""},
+		{broom.Parameter{Type: "string", Enum: []string{"draft", "published"}}, []string{"archived"}, `"archived" is not one of: draft, published`},
+		{broom.Parameter{Type: "[]string", Enum: []string{"draft", "published"}}, []string{"draft,archived"}, `"archived" is not one of: draft, published`},
+		{broom.Parameter{Type: "integer"}, []string{"1.5"}, `"1.5" is not a valid integer`},
+		{broom.Parameter{Type: "number"}, []string{"1.5"}, ""},
+		{broom.Parameter{Type: "boolean"}, []string{"yes"}, `"yes" is not a valid boolean`},
+		{broom.Parameter{Type: "integer", Constraints: broom.Constraints{Minimum: &one, Maximum: &ten}}, []string{"10"}, ""},
+		{broom.Parameter{Type: "integer", Constraints: broom.Constraints{Minimum: &one, Maximum: &ten}}, []string{"0"}, "0 is less than the minimum of 1"},
+		{broom.Parameter{Type: "integer", Constraints: broom.Constraints{Maximum: &ten, ExclusiveMaximum: true}}, []string{"10"}, "10 is greater than the maximum of 10 (exclusive)"},
+		{broom.Parameter{Type: "[]integer", Constraints: broom.Constraints{Maximum: &ten}}, []string{"5", "11"}, "11 is greater than the maximum of 10"},
+		{broom.Parameter{Type: "string", Constraints: broom.Constraints{MinLength: &minLength, MaxLength: &maxLength}}, []string{"été"}, ""},
+		{broom.Parameter{Type: "string", Constraints: broom.Constraints{MinLength: &minLength}}, []string{"a"}, `"a" is shorter than the minimum length of 2`},
+		{broom.Parameter{Type: "string", Constraints: broom.Constraints{MaxLength: &maxLength}}, []string{"abcde"}, `"abcde" is longer than the maximum length of 4`},
+		{broom.Parameter{Type: "string", Constraints: broom.Constraints{Pattern: "^[A-Z]{2}$"}}, []string{"US"}, ""},
+		{broom.Parameter{Type: "string", Constraints: broom.Constraints{Pattern: "^[A-Z]{2}$"}}, []string{"USA"}, `"USA" does not match the pattern ^[A-Z]{2}$`},
+		{broom.Parameter{Type: "string", Constraints: broom.Constraints{Pattern: "^(?!x)"}}, []string{"x"}, ""},
+		{broom.Parameter{Type: "string", Format: "date"}, []string{"2024-01-31"}, ""},
+		{broom.Parameter{Type: "string", Format: "date"}, []string{"2024-01-32"}, `"2024-01-32" is not a valid date`},
+		{broom.Parameter{Type: "string", Format: "date-time"}, []string{"2024-01-31T10:00:00Z"}, ""},
+		{broom.Parameter{Type: "string", Format: "date-time"}, []string{"2024-01-31"}, `"2024-01-31" is not a valid date-time`},
+		{broom.Parameter{Type: "string", Format: "date-time"}, []string{"30d"}, `"30d" is not a valid date-time`},
+		{broom.Parameter{Type: "string", Format: "date"}, []string{"2024-01-02T10:00:00Z"}, `"2024-01-02T10:00:00Z" is not a valid date`},
+		{broom.Parameter{In: "body", Type: "string", Format: "date-time"}, []string{"30d"}, ""},
+		{broom.Parameter{In: "body", Type: "string", Format: "date-time"}, []string{"2024-01-31"}, ""},
+		{broom.Parameter{In: "body", Type: "string", Format: "date"}, []string{"2024-01-02T10:00:00Z"}, ""},
+		{broom.Parameter{Type: "string", Format: "date-time"}, []string{"tomorrow"}, `"tomorrow" is not a valid date-time`},
+		{broom.Parameter{In: "body", Type: "string", Format: "date-time"}, []string{"+1d"}, ""},
+		{broom.Parameter{Type: "string", Format: "email"}, []string{"js@domain.com"}, ""},
+		{broom.Parameter{Type: "string", Format: "email"}, []string{"John <js@domain.com>"}, `"John <js@domain.com>" is not a valid email`},
+		{broom.Parameter{Type: "string", Format: "uuid"}, []string{"c3b6a7b2-5f1e-4d2a-9a3e-0f5d3c2b1a09"}, ""},
+		{broom.Parameter{Type: "string", Format: "uuid"}, []string{"c3b6a7b2"}, `"c3b6a7b2" is not a valid uuid`},
+		{broom.Parameter{Type: "string", Format: "ipv4"}, []string{"::1"}, `"::1" is not a valid ipv4`},
+		{broom.Parameter{Type: "string", Format: "uri"}, []string{"/relative"}, `"/relative" is not a valid uri`},
+		{broom.Parameter{Type: "integer", Format: "int32"}, []string{"3000000000"}, `"3000000000" is not a valid int32`},
+		{broom.Parameter{Type: "string", Format: "ulid"}, []string{"anything"}, ""},
+		{broom.Parameter{Type: "object"}, []string{"R=100"}, ""},
+		{broom.Parameter{Type: "integer", Nullable: true}, []string{"null"}, ""},
+		{broom.Parameter{Type: "integer"}, []string{"null"}, `"null" is not a valid integer`},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			err := tt.param.ValidateValues(tt.values)
+			gotErr := ""
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("got %q, want %q", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestParameter_Label(t *testing.T) {
 	// Conversion from snake_case.
 	param := broom.Parameter{
@@ -481,6 +1291,37 @@ func TestParameter_FormattedFlags(t *testing.T) {
 	if got != want {
 		t.Errorf("got %v, want %v", got, want)
 	}
+
+	param = broom.Parameter{
+		Name:     "nickname",
+		Required: true,
+		Nullable: true,
+	}
+	got = param.FormattedFlags()
+	want = "(required, nullable)"
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseCookieValues(t *testing.T) {
+	_, err := broom.ParseCookieValues([]string{"session"})
+	if err == nil {
+		t.Error("expected error, got nil")
+	} else if err.Error() != `parse cookie: could not parse "session"` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	values, err := broom.ParseCookieValues([]string{"session=abc123", " locale = en"})
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	want := url.Values{}
+	want.Add("session", "abc123")
+	want.Add("locale", "en")
+	if !cmp.Equal(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
 }
 
 func TestParseRequestValues(t *testing.T) {
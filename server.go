@@ -0,0 +1,116 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Server represents a server defined by the spec for a path or an operation.
+type Server struct {
+	URL         string
+	Description string
+	Variables   []ServerVariable
+}
+
+// ResolveURL resolves the server URL using the given variable values.
+//
+// Variables without a given value use their default value.
+func (s Server) ResolveURL(values map[string]string) (string, error) {
+	resolved := make(map[string]string, len(values)+len(s.Variables))
+	for name, value := range values {
+		resolved[name] = value
+	}
+	for _, v := range s.Variables {
+		if _, ok := values[v.Name]; !ok {
+			resolved[v.Name] = v.Default
+		}
+	}
+	if err := s.ValidateVariables(resolved); err != nil {
+		return "", err
+	}
+
+	return ExpandServerURL(s.URL, resolved)
+}
+
+// ValidateVariables checks the given values against the enums of the server variables.
+//
+// Values for variables not defined by the server are ignored.
+func (s Server) ValidateVariables(values map[string]string) error {
+	for _, v := range s.Variables {
+		value, ok := values[v.Name]
+		if ok && len(v.Enum) > 0 && !slices.Contains(v.Enum, value) {
+			return fmt.Errorf("invalid value %q for server variable %v, must be one of: %v", value, v.Name, strings.Join(v.Enum, ", "))
+		}
+	}
+
+	return nil
+}
+
+// ServerVariable represents a server URL variable.
+type ServerVariable struct {
+	Name        string
+	Description string
+	Default     string
+	Enum        []string
+}
+
+// serverVariablePattern matches server variable placeholders, e.g. {region}.
+var serverVariablePattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// ExpandServerURL replaces the variable placeholders in the given server URL.
+//
+// For example, https://{region}.my-api.io becomes https://eu.my-api.io
+// when given a "region" value of "eu".
+func ExpandServerURL(serverURL string, values map[string]string) (string, error) {
+	var missing []string
+	expanded := serverVariablePattern.ReplaceAllStringFunc(serverURL, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			return placeholder
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing value for server variables: %v", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
+// ParseServerVariables parses server variable values in the name=value format.
+func ParseServerVariables(vars []string) (map[string]string, error) {
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("parse server variable: could not parse %q", v)
+		}
+		values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	return values, nil
+}
+
+// joinServerURL resolves a relative server URL against the given base URL.
+//
+// Servers defined by the spec can be relative, e.g. "/v2".
+func joinServerURL(baseURL string, serverURL string) string {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.IsAbs() {
+		return serverURL
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return serverURL
+	}
+
+	return base.ResolveReference(u).String()
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom_test
+
+import (
+	"testing"
+
+	"github.com/bojanz/broom"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandServerURL(t *testing.T) {
+	tests := []struct {
+		serverURL string
+		values    map[string]string
+		want      string
+		wantErr   string
+	}{
+		{"https://my-api.io", nil, "https://my-api.io", ""},
+		{"https://{region}.my-api.io/{version}", map[string]string{"region": "eu", "version": "v2"}, "https://eu.my-api.io/v2", ""},
+		{"https://{region}.my-api.io/{version}", map[string]string{"region": "eu"}, "", "missing value for server variables: version"},
+		{"https://{region}.my-api.io/{version}", nil, "", "missing value for server variables: region, version"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := broom.ExpandServerURL(tt.serverURL, tt.values)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			errStr := ""
+			if err != nil {
+				errStr = err.Error()
+			}
+			if errStr != tt.wantErr {
+				t.Errorf("got error %q, want %q", errStr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseServerVariables(t *testing.T) {
+	got, err := broom.ParseServerVariables([]string{"region=eu", "version = v2"})
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	want := map[string]string{"region": "eu", "version": "v2"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = broom.ParseServerVariables([]string{"region"})
+	if err == nil || err.Error() != `parse server variable: could not parse "region"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestServer_ValidateVariables(t *testing.T) {
+	server := broom.Server{
+		URL: "https://{region}.my-api.io/{version}",
+		Variables: []broom.ServerVariable{
+			{Name: "region", Default: "us", Enum: []string{"eu", "us"}},
+			{Name: "version", Default: "v1"},
+		},
+	}
+	err := server.ValidateVariables(map[string]string{"region": "eu", "version": "v3", "other": "x"})
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	err = server.ValidateVariables(map[string]string{"region": "asia"})
+	wantErr := `invalid value "asia" for server variable region, must be one of: eu, us`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("got %v, want %v", err, wantErr)
+	}
+}
+
+func TestOperation_ServerURL(t *testing.T) {
+	server := broom.Server{
+		URL: "https://{region}.my-api.io",
+		Variables: []broom.ServerVariable{
+			{Name: "region", Default: "us", Enum: []string{"eu", "us"}},
+		},
+	}
+	tests := []struct {
+		servers    []broom.Server
+		defaultURL string
+		values     map[string]string
+		want       string
+		wantErr    string
+	}{
+		// No operation servers.
+		{nil, "https://my-api.io", nil, "https://my-api.io", ""},
+		{nil, "https://{region}.my-api.io", map[string]string{"region": "eu"}, "https://eu.my-api.io", ""},
+		// Operation server with a variable.
+		{[]broom.Server{server}, "https://my-api.io", nil, "https://us.my-api.io", ""},
+		{[]broom.Server{server}, "https://my-api.io", map[string]string{"region": "eu"}, "https://eu.my-api.io", ""},
+		{[]broom.Server{server}, "https://my-api.io", map[string]string{"region": "asia"}, "", "invalid value \"asia\" for server variable region, must be one of: eu, us"},
+		// Relative operation server.
+		{[]broom.Server{{URL: "/v2"}}, "https://my-api.io/v1", nil, "https://my-api.io/v2", ""},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			op := broom.Operation{Servers: tt.servers}
+			got, err := op.ServerURL(tt.defaultURL, tt.values)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			errStr := ""
+			if err != nil {
+				errStr = err.Error()
+			}
+			if errStr != tt.wantErr {
+				t.Errorf("got error %q, want %q", errStr, tt.wantErr)
+			}
+		})
+	}
+}
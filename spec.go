@@ -4,13 +4,19 @@
 package broom
 
 import (
-	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"hash/adler32"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"slices"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/iancoleman/strcase"
 	"github.com/pb33f/libopenapi"
@@ -18,83 +24,262 @@ import (
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
 )
 
-// LoadOperations loads available operations from a specification on disk.
+// SpecContents represents the operations, tags, and servers defined by a specification.
+type SpecContents struct {
+	Operations Operations `json:"operations"`
+	Tags       []Tag      `json:"tags"`
+	Servers    []Server   `json:"servers"`
+}
+
+// LoadOperations loads available operations from a specification.
+//
+// The specification can be on disk or at a remote URL.
 func LoadOperations(filename string) (Operations, error) {
-	spec, err := LoadSpec(filename)
+	contents, err := LoadSpecContents(filename)
+	return contents.Operations, err
+}
+
+// LoadSpecContents loads the operations, tags, and servers defined by a specification.
+//
+// The specification can be on disk or at a remote URL.
+// It is read and parsed only once.
+func LoadSpecContents(filename string) (SpecContents, error) {
+	b, err := readSpec(filename)
+	if err != nil {
+		return SpecContents{}, fmt.Errorf("load spec: %w", err)
+	}
+
+	return loadSpecContents(filename, b)
+}
+
+// loadSpecContents loads the operations, tags, and servers from the given specification contents.
+func loadSpecContents(filename string, b []byte) (SpecContents, error) {
+	spec, err := parseSpec(filename, b)
 	if err != nil {
-		return Operations{}, fmt.Errorf("load spec: %w", err)
+		return SpecContents{}, fmt.Errorf("load spec: %w", err)
+	}
+	contents := SpecContents{
+		Operations: Operations{},
+		Tags:       newTagsFromSpec(spec.Tags),
+		Servers:    newServersFromSpec(spec.Servers),
 	}
 	if spec.Paths == nil {
-		return Operations{}, nil
+		return contents, nil
 	}
 
-	ops := Operations{}
 	for pair := orderedmap.First(spec.Paths.PathItems); pair != nil; pair = pair.Next() {
 		path := pair.Key()
 		pathItem := pair.Value()
-		if pathItem.Get != nil {
-			ops = append(ops, newOperationFromSpec(http.MethodGet, path, pathItem.Parameters, *pathItem.Get))
+		for _, po := range pathItemOperations(pathItem) {
+			op := newOperationFromSpec(po.method, path, pathItem.Parameters, pathItem.Servers, spec.Security, *po.specOp)
+			contents.Operations = append(contents.Operations, op)
+		}
+	}
+	assignOperationIDs(contents.Operations)
+
+	return contents, nil
+}
+
+// pathOperation is an operation defined on a path item.
+type pathOperation struct {
+	method string
+	specOp *v3.Operation
+}
+
+// pathItemOperations returns the operations defined on the given path item.
+//
+// Operations are listed in the order in which they are usually defined,
+// with the rarely used methods (HEAD, OPTIONS, TRACE) at the end.
+func pathItemOperations(pathItem *v3.PathItem) []pathOperation {
+	all := []pathOperation{
+		{http.MethodGet, pathItem.Get},
+		{http.MethodPost, pathItem.Post},
+		{http.MethodPut, pathItem.Put},
+		{http.MethodPatch, pathItem.Patch},
+		{http.MethodDelete, pathItem.Delete},
+		{http.MethodHead, pathItem.Head},
+		{http.MethodOptions, pathItem.Options},
+		{http.MethodTrace, pathItem.Trace},
+	}
+	pathOps := make([]pathOperation, 0, len(all))
+	for _, po := range all {
+		if po.specOp != nil {
+			pathOps = append(pathOps, po)
+		}
+	}
+
+	return pathOps
+}
+
+// newTagsFromSpec creates a new list of tags from the loaded specification.
+func newTagsFromSpec(specTags []*base.Tag) []Tag {
+	var tags []Tag
+	for _, specTag := range specTags {
+		tags = append(tags, Tag{
+			Name:        specTag.Name,
+			Description: Sanitize(specTag.Description),
+		})
+	}
+
+	return tags
+}
+
+// assignOperationIDs ensures that all operations have a unique ID.
+//
+// Operations without an ID get one generated from their method and path,
+// e.g. GET /products/{product_id} becomes get-products-product-id.
+// IDs defined by the spec can also clash once kebab-cased (listProducts
+// and list-products), in which case the first operation keeps the ID.
+// Generated and clashing IDs get a numeric suffix when already taken (get-products-2).
+// Clashes are reported by Lint.
+func assignOperationIDs(ops Operations) {
+	taken := make(map[string]struct{}, len(ops))
+	duplicates := make(map[int]bool)
+	for i, op := range ops {
+		if op.ID == "" {
+			continue
+		}
+		if _, ok := taken[op.ID]; ok {
+			duplicates[i] = true
+			continue
+		}
+		taken[op.ID] = struct{}{}
+	}
+	for i, op := range ops {
+		if op.ID != "" && !duplicates[i] {
+			continue
 		}
-		if pathItem.Post != nil {
-			ops = append(ops, newOperationFromSpec(http.MethodPost, path, pathItem.Parameters, *pathItem.Post))
+		baseID := op.ID
+		if baseID == "" {
+			baseID = generateOperationID(op.Method, op.Path)
 		}
-		if pathItem.Put != nil {
-			ops = append(ops, newOperationFromSpec(http.MethodPut, path, pathItem.Parameters, *pathItem.Put))
+		id := baseID
+		for n := 2; ; n++ {
+			if _, ok := taken[id]; !ok {
+				break
+			}
+			id = baseID + "-" + strconv.Itoa(n)
 		}
-		if pathItem.Patch != nil {
-			ops = append(ops, newOperationFromSpec(http.MethodPatch, path, pathItem.Parameters, *pathItem.Patch))
+		ops[i].ID = id
+		taken[id] = struct{}{}
+	}
+}
+
+// generateOperationID generates an operation ID from the given method and path.
+//
+// Words are separated by dashes, including the ones in camelCase path segments.
+// Unlike strcase.ToKebab, digits are not treated as separate words (v2, not v-2).
+func generateOperationID(method string, path string) string {
+	var sb strings.Builder
+	var prev rune
+	for _, r := range strings.ToLower(method) + "/" + path {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			r = '-'
+		} else if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			sb.WriteRune('-')
 		}
-		if pathItem.Delete != nil {
-			ops = append(ops, newOperationFromSpec(http.MethodDelete, path, pathItem.Parameters, *pathItem.Delete))
+		if r != '-' || prev != '-' {
+			sb.WriteRune(unicode.ToLower(r))
 		}
+		prev = r
 	}
 
-	return ops, nil
+	return strings.TrimSuffix(sb.String(), "-")
 }
 
-// LoadSpec loads an OpenAPI 3.0/3.1 specification from disk.
+// LoadSpec loads an OpenAPI 3.0/3.1 or Swagger 2.0 specification.
+//
+// The specification can be on disk or at a remote URL, in which case
+// it is cached locally and revalidated on each load.
+// Swagger 2.0 specifications are converted to OpenAPI 3.
 func LoadSpec(filename string) (v3.Document, error) {
-	b, err := os.ReadFile(filename)
+	b, err := readSpec(filename)
 	if err != nil {
 		return v3.Document{}, err
 	}
-	doc, err := libopenapi.NewDocumentWithConfiguration(b, &datamodel.DocumentConfiguration{
+
+	return parseSpec(filename, b)
+}
+
+// readSpec reads the contents of a specification on disk or at a remote URL.
+func readSpec(filename string) ([]byte, error) {
+	if IsURL(filename) {
+		return fetchSpec(filename)
+	}
+	return os.ReadFile(filename)
+}
+
+// parseSpec parses the given specification contents.
+func parseSpec(filename string, b []byte) (v3.Document, error) {
+	doc, errs := buildSpec(filename, b)
+	if len(errs) > 0 {
+		return v3.Document{}, errors.Join(errs...)
+	}
+
+	return *doc, nil
+}
+
+// buildSpec builds a document model from the given specification contents.
+//
+// Non-fatal errors (such as unresolvable references) are returned together
+// with the document, allowing it to be inspected regardless (see Lint).
+func buildSpec(filename string, b []byte) (*v3.Document, []error) {
+	docCfg := &datamodel.DocumentConfiguration{
 		AllowRemoteReferences: true,
-	})
+		// Errors are returned, there is no need to also log them.
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	if IsURL(filename) {
+		// Allow relative references to be resolved against the spec URL.
+		if baseURL, err := url.Parse(filename); err == nil {
+			baseURL.Path = path.Dir(baseURL.Path)
+			docCfg.BaseURL = baseURL
+		}
+	}
+	doc, err := libopenapi.NewDocumentWithConfiguration(b, docCfg)
 	if err != nil {
-		return v3.Document{}, err
+		return nil, []error{err}
+	}
+	if doc.GetSpecInfo().SpecFormat == datamodel.OAS2 {
+		m, errs := doc.BuildV2Model()
+		if m == nil {
+			return nil, errs
+		}
+		converted := convertSwagger(filename, m.Model, doc.GetSpecInfo().RootNode)
+		return &converted, errs
 	}
 	m, errs := doc.BuildV3Model()
-	if len(errs) > 0 {
-		return v3.Document{}, errors.Join(errs...)
+	if m == nil {
+		return nil, errs
 	}
 
-	return m.Model, nil
+	return &m.Model, errs
 }
 
 // newOperationFromSpec creates a new operation from the loaded specification.
-func newOperationFromSpec(method string, path string, params []*v3.Parameter, specOp v3.Operation) Operation {
+func newOperationFromSpec(method string, path string, params []*v3.Parameter, servers []*v3.Server, security []*base.SecurityRequirement, specOp v3.Operation) Operation {
 	op := Operation{
 		ID:          strcase.ToKebab(specOp.OperationId),
 		Summary:     specOp.Summary,
 		Description: Sanitize(specOp.Description),
 		Method:      method,
 		Path:        path,
+		Tags:        specOp.Tags,
 		Deprecated:  getBool(specOp.Deprecated),
 	}
-	// Make it possible to run operations without an ID.
-	if op.ID == "" {
-		// A hash like c5430c97 is better than nothing, though in the future we
-		// could try to generate a more user-friendly machine name from the path.
-		hash := adler32.New()
-		hash.Write([]byte(path))
-		op.ID = hex.EncodeToString(hash.Sum(nil))
+	// Servers can be overridden per-path or per-operation.
+	if len(specOp.Servers) > 0 {
+		servers = specOp.Servers
 	}
-	if len(specOp.Tags) > 0 {
-		op.Tag = specOp.Tags[0]
+	op.Servers = newServersFromSpec(servers)
+	// Security requirements can be overridden per-operation.
+	if specOp.Security != nil {
+		security = specOp.Security
 	}
+	op.Security = newSecurityFromSpec(security)
 	// Parameters can be defined per-path or per-operation.
 	for _, param := range params {
 		op.Parameters.Add(newParameterFromSpec(*param))
@@ -103,58 +288,235 @@ func newOperationFromSpec(method string, path string, params []*v3.Parameter, sp
 		op.Parameters.Add(newParameterFromSpec(*param))
 	}
 	if specOp.RequestBody != nil && specOp.RequestBody.Content != nil {
-		pair := orderedmap.First(specOp.RequestBody.Content)
-		format := pair.Key()
-		mediaType := pair.Value()
-		mediaTypeSchema := mediaType.Schema.Schema()
-
-		op.Parameters.Add(newBodyParameters("", mediaTypeSchema)...)
-		op.BodyFormat = format
+		for pair := orderedmap.First(specOp.RequestBody.Content); pair != nil; pair = pair.Next() {
+			body := Body{Format: pair.Key()}
+			mediaType := pair.Value()
+			if mediaType.Schema != nil {
+				if mediaTypeSchema := mediaType.Schema.Schema(); mediaTypeSchema != nil {
+					body.Parameters = newBodyParameters(mediaType.Schema, mediaTypeSchema)
+					body.Variants = newBodyVariants(mediaTypeSchema)
+				}
+			}
+			for encodingPair := orderedmap.First(mediaType.Encoding); encodingPair != nil; encodingPair = encodingPair.Next() {
+				if contentType := encodingPair.Value().ContentType; contentType != "" {
+					if body.Encoding == nil {
+						body.Encoding = make(map[string]string)
+					}
+					body.Encoding[encodingPair.Key()] = contentType
+				}
+			}
+			op.Bodies = append(op.Bodies, body)
+		}
+	}
+	if len(op.Bodies) > 0 {
+		// Default to JSON when available, since it supports typed values.
+		defaultFormat := op.Bodies[0].Format
+		for _, body := range op.Bodies {
+			if IsJSON(body.Format) {
+				defaultFormat = body.Format
+				break
+			}
+		}
+		op, _ = op.WithBodyFormat(defaultFormat)
+	}
+	if specOp.Responses != nil {
+		for pair := orderedmap.First(specOp.Responses.Codes); pair != nil; pair = pair.Next() {
+			op.Responses = append(op.Responses, newResponseFromSpec(pair.Key(), *pair.Value()))
+		}
+		if specOp.Responses.Default != nil {
+			op.Responses = append(op.Responses, newResponseFromSpec("default", *specOp.Responses.Default))
+		}
 	}
 
 	return op
 }
 
+// newSecurityFromSpec creates a list of security requirements from the loaded specification.
+//
+// An empty list (security: []) marks the operation as public,
+// which is represented by a single empty requirement.
+func newSecurityFromSpec(specSecurity []*base.SecurityRequirement) []SecurityRequirement {
+	if specSecurity == nil {
+		return nil
+	}
+	security := make([]SecurityRequirement, 0, len(specSecurity))
+	for _, specReq := range specSecurity {
+		req := SecurityRequirement{}
+		if specReq != nil {
+			for pair := orderedmap.First(specReq.Requirements); pair != nil; pair = pair.Next() {
+				req = append(req, pair.Key())
+			}
+		}
+		security = append(security, req)
+	}
+	if len(security) == 0 {
+		security = append(security, SecurityRequirement{})
+	}
+
+	return security
+}
+
+// newResponseFromSpec creates a new response from the loaded specification.
+//
+// The schema and example are taken from the JSON media type, when available.
+func newResponseFromSpec(statusCode string, specResp v3.Response) Response {
+	resp := Response{
+		StatusCode:  statusCode,
+		Description: Sanitize(specResp.Description),
+	}
+	var mediaType *v3.MediaType
+	for pair := orderedmap.First(specResp.Content); pair != nil; pair = pair.Next() {
+		resp.Formats = append(resp.Formats, pair.Key())
+		if mediaType == nil || (IsJSON(pair.Key()) && !IsJSON(resp.Formats[0])) {
+			mediaType = pair.Value()
+		}
+	}
+	if mediaType == nil {
+		return resp
+	}
+	var schema *base.Schema
+	if mediaType.Schema != nil {
+		schema = mediaType.Schema.Schema()
+	}
+	if schema != nil {
+		resp.Type = getSchemaType(schema)
+		fieldSchema := schema
+		if schema.Items != nil && schema.Items.IsA() {
+			fieldSchema = schema.Items.A.Schema()
+		}
+		if fieldSchema != nil {
+			properties, _ := getSchemaProperties(fieldSchema)
+			for pair := orderedmap.First(properties); pair != nil; pair = pair.Next() {
+				resp.Fields = append(resp.Fields, pair.Key())
+			}
+		}
+	}
+	// Examples can be defined on the media type, or on the schema itself.
+	if mediaType.Example != nil {
+		resp.Example = formatExample(mediaType.Example)
+	} else if pair := orderedmap.First(mediaType.Examples); pair != nil && pair.Value().Value != nil {
+		resp.Example = formatExample(pair.Value().Value)
+	} else if schema != nil && schema.Example != nil {
+		resp.Example = formatExample(schema.Example)
+	}
+
+	return resp
+}
+
+// newServersFromSpec creates a list of servers from the loaded specification.
+func newServersFromSpec(specServers []*v3.Server) []Server {
+	var servers []Server
+	for _, specServer := range specServers {
+		servers = append(servers, newServerFromSpec(*specServer))
+	}
+
+	return servers
+}
+
+// newServerFromSpec creates a new server from the loaded specification.
+func newServerFromSpec(specServer v3.Server) Server {
+	server := Server{
+		URL:         specServer.URL,
+		Description: Sanitize(specServer.Description),
+	}
+	for pair := orderedmap.First(specServer.Variables); pair != nil; pair = pair.Next() {
+		specVariable := pair.Value()
+		server.Variables = append(server.Variables, ServerVariable{
+			Name:        pair.Key(),
+			Description: Sanitize(specVariable.Description),
+			Default:     specVariable.Default,
+			Enum:        specVariable.Enum,
+		})
+	}
+
+	return server
+}
+
 // newParameterFromSpec creates a new parameter from the loaded specification.
 func newParameterFromSpec(specParam v3.Parameter) Parameter {
-	schema := specParam.Schema.Schema()
+	// Parameters can use "content" instead of "schema", which is not supported.
+	schema := &base.Schema{}
+	if specParam.Schema != nil {
+		if paramSchema := specParam.Schema.Schema(); paramSchema != nil {
+			schema = paramSchema
+		}
+	}
+
+	style := specParam.Style
+	if style == "" {
+		style = defaultStyle(specParam.In)
+	}
+	// Only the form style is exploded by default.
+	explode := style == "form"
+	if specParam.Explode != nil {
+		explode = *specParam.Explode
+	}
 
 	return Parameter{
-		In:          specParam.In,
-		Name:        specParam.Name,
-		Description: Sanitize(specParam.Description),
-		Style:       specParam.Style,
-		Type:        getSchemaType(schema),
-		Enum:        getEnum(schema),
-		Example:     getExample(schema),
-		Default:     getDefaultValue(schema),
-		Deprecated:  specParam.Deprecated,
-		Required:    getBool(specParam.Required),
+		In:            specParam.In,
+		Name:          specParam.Name,
+		Description:   Sanitize(specParam.Description),
+		Style:         style,
+		Explode:       explode,
+		AllowReserved: specParam.AllowReserved,
+		Type:          getSchemaType(schema),
+		Format:        getFormat(schema),
+		Constraints:   getConstraints(schema),
+		Nullable:      isNullable(schema),
+		Enum:          getEnum(schema),
+		Example:       getExample(schema),
+		Default:       getDefaultValue(schema),
+		Deprecated:    specParam.Deprecated,
+		Required:      getBool(specParam.Required),
 	}
 }
 
 // newBodyParameters creates a slice of body parameters from the given schema.
-func newBodyParameters(prefix string, schema *base.Schema) []Parameter {
-	parameters := make([]Parameter, 0, 10)
-	for pair := orderedmap.First(schema.Properties); pair != nil; pair = pair.Next() {
+func newBodyParameters(proxy *base.SchemaProxy, schema *base.Schema) []Parameter {
+	return collectBodyParameters("", schema, appendRef(nil, proxy))
+}
+
+// collectBodyParameters collects the body parameters of the given schema.
+//
+// The refs are the schema references expanded so far. Recursive schemas
+// (e.g. a category with child categories) are not expanded again, and are
+// treated as a single object or []object parameter instead.
+func collectBodyParameters(prefix string, schema *base.Schema, refs []string) []Parameter {
+	properties, required := getSchemaProperties(schema)
+	var parameters []Parameter
+	for pair := orderedmap.First(properties); pair != nil; pair = pair.Next() {
 		propertyName := pair.Key()
-		propertySchema := pair.Value().Schema()
+		propertyProxy := pair.Value()
+		propertySchema := propertyProxy.Schema()
+		if propertySchema == nil {
+			continue
+		}
 		propertySchemaType := getSchemaType(propertySchema)
 
-		if propertySchemaType == "object" {
+		if propertySchemaType == "object" && !isRecursiveRef(propertyProxy, refs) {
 			// Nested parameters found, flatten them.
-			parameters = append(parameters, newBodyParameters(prefix+propertyName+".", propertySchema)...)
+			propertyRefs := appendRef(refs, propertyProxy)
+			parameters = append(parameters, collectBodyParameters(prefix+propertyName+".", propertySchema, propertyRefs)...)
+		} else if propertySchemaType == "[]object" && !isRecursiveRef(propertySchema.Items.A, refs) {
+			// Array of objects found, flatten the item parameters.
+			// Indexes are added by the user when specifying values: items[0].sku.
+			itemRefs := appendRef(refs, propertySchema.Items.A)
+			itemSchema := propertySchema.Items.A.Schema()
+			parameters = append(parameters, collectBodyParameters(prefix+propertyName+"[].", itemSchema, itemRefs)...)
 		} else {
 			parameters = append(parameters, Parameter{
 				In:          "body",
 				Name:        prefix + propertyName,
 				Description: Sanitize(propertySchema.Description),
 				Type:        propertySchemaType,
+				Format:      getFormat(propertySchema),
+				Constraints: getConstraints(propertySchema),
+				Nullable:    isNullable(propertySchema),
 				Enum:        getEnum(propertySchema),
 				Example:     getExample(propertySchema),
 				Default:     getDefaultValue(propertySchema),
 				Deprecated:  getBool(propertySchema.Deprecated),
-				Required:    slices.Contains(schema.Required, propertyName),
+				Required:    slices.Contains(required, propertyName),
 			})
 		}
 	}
@@ -162,24 +524,204 @@ func newBodyParameters(prefix string, schema *base.Schema) []Parameter {
 	return parameters
 }
 
+// isRecursiveRef checks whether the given schema proxy references an already expanded schema.
+func isRecursiveRef(proxy *base.SchemaProxy, refs []string) bool {
+	return proxy.IsReference() && slices.Contains(refs, proxy.GetReference())
+}
+
+// appendRef appends the reference of the given schema proxy, if any, to refs.
+func appendRef(refs []string, proxy *base.SchemaProxy) []string {
+	if !proxy.IsReference() {
+		return refs
+	}
+
+	return append(slices.Clip(refs), proxy.GetReference())
+}
+
+// newBodyVariants creates a slice of body variants from the given schema.
+//
+// Variants are defined via oneOf (or anyOf), and are named after their
+// discriminator value, title, or schema name, in that order.
+func newBodyVariants(schema *base.Schema) []BodyVariant {
+	proxies := schema.OneOf
+	if len(proxies) == 0 {
+		proxies = schema.AnyOf
+	}
+	if len(proxies) == 0 {
+		return nil
+	}
+	variants := make([]BodyVariant, 0, len(proxies))
+	for i, proxy := range proxies {
+		variantSchema := proxy.Schema()
+		if variantSchema == nil {
+			continue
+		}
+		variant := BodyVariant{
+			Description: Sanitize(variantSchema.Description),
+			Parameters:  newBodyParameters(proxy, variantSchema),
+		}
+		refName := ""
+		if proxy.IsReference() {
+			ref := proxy.GetReference()
+			refName = ref[strings.LastIndex(ref, "/")+1:]
+		}
+		if d := schema.Discriminator; d != nil && d.PropertyName != "" {
+			for pair := orderedmap.First(d.Mapping); pair != nil; pair = pair.Next() {
+				if proxy.IsReference() && pair.Value() == proxy.GetReference() {
+					variant.Name = pair.Key()
+					break
+				}
+			}
+			// Without a mapping, the schema name is used as the discriminator value.
+			if variant.Name == "" && d.Mapping == nil {
+				variant.Name = refName
+			}
+			if variant.Name != "" {
+				variant.Discriminator = d.PropertyName
+			}
+		}
+		if variant.Name == "" && variantSchema.Title != "" {
+			variant.Name = strcase.ToKebab(variantSchema.Title)
+		}
+		if variant.Name == "" && refName != "" {
+			variant.Name = strcase.ToKebab(refName)
+		}
+		if variant.Name == "" {
+			variant.Name = strconv.Itoa(i + 1)
+		}
+		variants = append(variants, variant)
+	}
+
+	return variants
+}
+
+// getSchemaProperties retrieves the properties and required property names of the given schema.
+//
+// Schemas composed via allOf are merged into a single set of properties.
+func getSchemaProperties(schema *base.Schema) (*orderedmap.Map[string, *base.SchemaProxy], []string) {
+	properties := orderedmap.New[string, *base.SchemaProxy]()
+	required := slices.Clone(schema.Required)
+	for _, proxy := range schema.AllOf {
+		subschema := proxy.Schema()
+		if subschema == nil {
+			continue
+		}
+		subproperties, subrequired := getSchemaProperties(subschema)
+		for pair := orderedmap.First(subproperties); pair != nil; pair = pair.Next() {
+			properties.Set(pair.Key(), pair.Value())
+		}
+		required = append(required, subrequired...)
+	}
+	for pair := orderedmap.First(schema.Properties); pair != nil; pair = pair.Next() {
+		properties.Set(pair.Key(), pair.Value())
+	}
+
+	return properties, required
+}
+
 // getSchemaType retrieves the type of the given schema.
 func getSchemaType(schema *base.Schema) string {
+	return resolveSchemaType(schema, nil)
+}
+
+// resolveSchemaType resolves the type of the given schema.
+//
+// The refs are the item schema references expanded so far, used to stop
+// at recursive item schemas (e.g. a tree defined as an array of trees).
+func resolveSchemaType(schema *base.Schema, refs []string) string {
+	if len(schema.Type) == 0 {
+		// Composed schemas often omit the type.
+		if schema.Properties != nil || len(schema.AllOf) > 0 {
+			return "object"
+		}
+		return ""
+	}
 	// schema.Type can contain multiple values in OpenAPI 3.1, e.g:
 	// [string, null] or [string, integer]. Broom needs a single type
 	// so that it can cast the value (see Parameter#CastString).
+	// The null type is tracked separately (see isNullable).
 	schemaType := schema.Type[0]
+	if schemaType == "null" && len(schema.Type) > 1 {
+		schemaType = schema.Type[1]
+	}
 	// Expand the array type into the underlying type (array -> []string).
-	if schemaType == "array" && schema.Items.IsA() {
-		schemaType = fmt.Sprintf("[]%v", schema.Items.A.Schema().Type[0])
+	if schemaType == "array" && schema.Items != nil && schema.Items.IsA() {
+		itemProxy := schema.Items.A
+		if itemSchema := itemProxy.Schema(); itemSchema != nil {
+			if isRecursiveRef(itemProxy, refs) {
+				return "[]array"
+			}
+			schemaType = fmt.Sprintf("[]%v", resolveSchemaType(itemSchema, appendRef(refs, itemProxy)))
+		}
 	}
 
 	return schemaType
 }
 
+// getFormat retrieves the format of the given schema.
+//
+// The item format is used for arrays (e.g. uuid for an array of UUIDs).
+func getFormat(schema *base.Schema) string {
+	return itemSchema(schema).Format
+}
+
+// getConstraints retrieves the value constraints defined on the given schema.
+//
+// The item constraints are used for arrays, since values are validated per item.
+func getConstraints(schema *base.Schema) Constraints {
+	schema = itemSchema(schema)
+	c := Constraints{
+		Minimum:   schema.Minimum,
+		Maximum:   schema.Maximum,
+		MinLength: schema.MinLength,
+		MaxLength: schema.MaxLength,
+		Pattern:   schema.Pattern,
+	}
+	// OpenAPI 3.0 uses booleans for exclusive bounds, 3.1 uses numbers.
+	if schema.ExclusiveMinimum != nil {
+		if schema.ExclusiveMinimum.IsA() {
+			c.ExclusiveMinimum = schema.ExclusiveMinimum.A
+		} else {
+			c.Minimum = &schema.ExclusiveMinimum.B
+			c.ExclusiveMinimum = true
+		}
+	}
+	if schema.ExclusiveMaximum != nil {
+		if schema.ExclusiveMaximum.IsA() {
+			c.ExclusiveMaximum = schema.ExclusiveMaximum.A
+		} else {
+			c.Maximum = &schema.ExclusiveMaximum.B
+			c.ExclusiveMaximum = true
+		}
+	}
+
+	return c
+}
+
+// itemSchema returns the item schema for arrays, and the given schema otherwise.
+func itemSchema(schema *base.Schema) *base.Schema {
+	if slices.Contains(schema.Type, "array") && schema.Items != nil && schema.Items.IsA() {
+		if itemSchema := schema.Items.A.Schema(); itemSchema != nil {
+			return itemSchema
+		}
+	}
+
+	return schema
+}
+
+// isNullable checks whether the given schema accepts null values.
+//
+// OpenAPI 3.0 uses the nullable keyword, 3.1 adds "null" to the list of types.
+func isNullable(schema *base.Schema) bool {
+	return getBool(schema.Nullable) || slices.Contains(schema.Type, "null")
+}
+
 // getEnum retrieves the enum values defined on the given schema.
+//
+// The item enum is used for arrays, since values are validated per item.
 func getEnum(schema *base.Schema) []string {
 	var enum []string
-	for _, v := range schema.Enum {
+	for _, v := range itemSchema(schema).Enum {
 		enum = append(enum, v.Value)
 	}
 
@@ -198,6 +740,25 @@ func getExample(schema *base.Schema) string {
 	return exampleValue
 }
 
+// formatExample formats the given example node as compact JSON.
+//
+// Scalar values are returned as-is.
+func formatExample(node *yaml.Node) string {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
 // getDefaultValue retrieves the default value defined on the given schema.
 func getDefaultValue(schema *base.Schema) string {
 	if schema.Default == nil {
@@ -4,6 +4,8 @@
 package broom_test
 
 import (
+	"bytes"
+	"os"
 	"testing"
 
 	"github.com/bojanz/broom"
@@ -11,34 +13,122 @@ import (
 )
 
 func TestLoadOperations(t *testing.T) {
+	minPrice := 0.0
 	idParam := broom.Parameter{
 		In:          "path",
 		Name:        "product_id",
 		Description: "The ID of the product.",
+		Style:       "simple",
 		Type:        "string",
+		Format:      "ulid",
 		Required:    true,
 	}
+	productFields := []string{
+		"id", "owner_id", "name", "sku", "description", "price",
+		"currency_code", "status", "created_at", "updated_at", "_links",
+	}
+	errorResponse := broom.Response{
+		StatusCode:  "default",
+		Description: "Error response.",
+		Formats:     []string{"application/problem+json"},
+		Type:        "object",
+		Fields:      []string{"type", "title", "status", "detail"},
+	}
 	vendorParam := broom.Parameter{
 		In:          "header",
 		Name:        "X-Vendor",
 		Description: "The vendor.",
+		Style:       "simple",
 		Type:        "string",
 	}
+	updateProductBody := broom.ParameterList{
+		broom.Parameter{
+			In:          "body",
+			Name:        "name",
+			Description: "The product name.",
+			Type:        "string",
+		},
+		broom.Parameter{
+			In:          "body",
+			Name:        "sku",
+			Description: "The product sku.",
+			Type:        "string",
+		},
+		broom.Parameter{
+			In:          "body",
+			Name:        "description",
+			Description: "The product description.",
+			Type:        "string",
+			Nullable:    true,
+		},
+		broom.Parameter{
+			In:          "body",
+			Name:        "price",
+			Description: "The product price, in cents.",
+			Type:        "integer",
+		},
+		broom.Parameter{
+			In:          "body",
+			Name:        "currency_code",
+			Description: "The currency code.",
+			Type:        "string",
+			Enum:        []string{"EUR", "USD"},
+		},
+		broom.Parameter{
+			In:          "body",
+			Name:        "meta.published",
+			Description: "Whether the product is visible to customers.",
+			Type:        "boolean",
+		},
+		broom.Parameter{
+			In:          "body",
+			Name:        "meta.in_stock",
+			Description: "Whether the product is in stock.",
+			Type:        "boolean",
+		},
+	}
+	uploadImageBody := broom.ParameterList{
+		broom.Parameter{
+			In:          "body",
+			Name:        "image",
+			Description: "The image file.",
+			Type:        "string",
+			Format:      "binary",
+		},
+		broom.Parameter{
+			In:          "body",
+			Name:        "alt",
+			Description: "The image alt text.",
+			Type:        "string",
+		},
+	}
 	wantOps := broom.Operations{
 		broom.Operation{
 			ID:          "list-products",
 			Summary:     "List products",
 			Description: "Retrieves a list of products matching the specified criteria.",
-			Tag:         "Products",
+			Tags:        []string{"Products"},
 			Method:      "GET",
 			Path:        "/products",
 			Parameters: broom.Parameters{
 				Header: broom.ParameterList{vendorParam},
+				Cookie: broom.ParameterList{
+					broom.Parameter{
+						In:          "cookie",
+						Name:        "locale",
+						Description: "The locale used for translating product names.",
+						Style:       "form",
+						Explode:     true,
+						Type:        "string",
+					},
+				},
 				Query: broom.ParameterList{
 					broom.Parameter{
 						In:          "query",
 						Name:        "filter[owner_id]",
 						Description: "Allows filtering by owner_id.",
+						Style:       "form",
+						Explode:     true,
 						Type:        "string",
 						Deprecated:  true,
 					},
@@ -46,29 +136,56 @@ func TestLoadOperations(t *testing.T) {
 						In:          "query",
 						Name:        "page[before]",
 						Description: "Shows 50 products before the given ID.",
+						Style:       "form",
+						Explode:     true,
 						Type:        "string",
+						Format:      "ulid",
 					},
 					broom.Parameter{
 						In:          "query",
 						Name:        "page[after]",
 						Description: "Shows 50 products after the given ID.",
+						Style:       "form",
+						Explode:     true,
 						Type:        "string",
+						Format:      "ulid",
+					},
+					broom.Parameter{
+						In:          "query",
+						Name:        "filter[status]",
+						Description: "Allows filtering by status.",
+						Style:       "form",
+						Explode:     true,
+						Type:        "[]string",
+						Enum:        []string{"draft", "published"},
 					},
 					broom.Parameter{
 						In:          "query",
 						Name:        "sort",
 						Description: "Allows sorting by a single field.\nUse a dash (\"-\") to sort descending.",
+						Style:       "form",
+						Explode:     true,
 						Type:        "string",
 						Default:     "created_at",
 					},
 				},
 			},
+			Responses: []broom.Response{
+				{
+					StatusCode:  "200",
+					Description: "Successful response.",
+					Formats:     []string{"application/json"},
+					Type:        "object",
+					Fields:      []string{"items", "_links"},
+				},
+				errorResponse,
+			},
 		},
 		broom.Operation{
 			ID:          "create-product",
 			Summary:     "Create product",
 			Description: "Creates a new product.",
-			Tag:         "Products",
+			Tags:        []string{"Products"},
 			Method:      "POST",
 			Path:        "/products",
 			Parameters: broom.Parameters{
@@ -79,6 +196,7 @@ func TestLoadOperations(t *testing.T) {
 						Name:        "owner_id",
 						Description: "ID of the owner. Defaults to the requester.",
 						Type:        "string",
+						Format:      "uuid",
 					},
 					broom.Parameter{
 						In:          "body",
@@ -104,6 +222,7 @@ func TestLoadOperations(t *testing.T) {
 						Name:        "price",
 						Description: "The product price, in cents.",
 						Type:        "integer",
+						Constraints: broom.Constraints{Minimum: &minPrice},
 						Example:     "1099",
 						Required:    true,
 					},
@@ -137,95 +256,460 @@ func TestLoadOperations(t *testing.T) {
 						Type:        "boolean",
 						Default:     "true",
 					},
+					broom.Parameter{
+						In:          "body",
+						Name:        "variants[].sku",
+						Description: "The variant sku.",
+						Type:        "string",
+						Required:    true,
+					},
+					broom.Parameter{
+						In:          "body",
+						Name:        "variants[].price",
+						Description: "The variant price, in cents.",
+						Type:        "integer",
+					},
 				},
 			},
 			BodyFormat: "application/json",
+			Responses: []broom.Response{
+				{
+					StatusCode:  "201",
+					Description: "Successful response.",
+					Formats:     []string{"application/json"},
+					Type:        "object",
+					Fields:      productFields,
+				},
+				errorResponse,
+			},
 		},
 		broom.Operation{
 			ID:          "get-product",
 			Summary:     "Get product",
 			Description: "Retrieves the specified product.",
-			Tag:         "Products",
+			Tags:        []string{"Products"},
 			Method:      "GET",
 			Path:        "/products/{product_id}",
 			Parameters: broom.Parameters{
 				Header: broom.ParameterList{vendorParam},
 				Path:   broom.ParameterList{idParam},
 			},
+			Responses: []broom.Response{
+				{
+					StatusCode:  "200",
+					Description: "Successful response.",
+					Formats:     []string{"application/json"},
+					Type:        "object",
+					Fields:      productFields,
+					Example:     `{"id":"01ARZ3NDEKTSV4RRFFQ69G5FAV","name":"Hat","price":1099}`,
+				},
+				errorResponse,
+			},
 		},
 		broom.Operation{
 			ID:          "update-product",
 			Summary:     "Update product",
 			Description: "Updates the specified product.",
-			Tag:         "Products",
+			Tags:        []string{"Products"},
 			Method:      "PATCH",
 			Path:        "/products/{product_id}",
 			Parameters: broom.Parameters{
 				Header: broom.ParameterList{vendorParam},
 				Path:   broom.ParameterList{idParam},
+				Body:   updateProductBody,
+			},
+			BodyFormat: "application/json",
+			Bodies: []broom.Body{
+				{Format: "application/json", Parameters: updateProductBody},
+				{
+					Format: "application/x-www-form-urlencoded",
+					Parameters: broom.ParameterList{
+						broom.Parameter{
+							In:          "body",
+							Name:        "name",
+							Description: "The product name.",
+							Type:        "string",
+						},
+						broom.Parameter{
+							In:          "body",
+							Name:        "sku",
+							Description: "The product sku.",
+							Type:        "string",
+						},
+					},
+				},
+			},
+			Responses: []broom.Response{
+				{
+					StatusCode:  "200",
+					Description: "Successful response.",
+					Formats:     []string{"application/json"},
+					Type:        "object",
+					Fields:      productFields,
+				},
+				errorResponse,
+			},
+		},
+		broom.Operation{
+			ID:          "delete-product",
+			Summary:     "Delete product",
+			Description: "Deletes the specified product.",
+			Tags:        []string{"Products"},
+			Method:      "DELETE",
+			Path:        "/products/{product_id}",
+			Parameters: broom.Parameters{
+				Header: broom.ParameterList{vendorParam},
+				Path:   broom.ParameterList{idParam},
+			},
+			Responses: []broom.Response{
+				{StatusCode: "204", Description: "Product deleted."},
+				errorResponse,
+			},
+		},
+		broom.Operation{
+			ID:          "check-product",
+			Summary:     "Check product",
+			Description: "Checks whether the specified product exists.",
+			Tags:        []string{"Products"},
+			Method:      "HEAD",
+			Path:        "/products/{product_id}",
+			Parameters: broom.Parameters{
+				Header: broom.ParameterList{vendorParam},
+				Path:   broom.ParameterList{idParam},
+			},
+			Responses: []broom.Response{
+				{StatusCode: "200", Description: "Product exists."},
+				{StatusCode: "404", Description: "Product not found."},
+			},
+		},
+		broom.Operation{
+			ID:          "upload-product-image",
+			Summary:     "Upload product image",
+			Description: "Uploads an image for the specified product.",
+			Tags:        []string{"Products", "Images"},
+			Method:      "PUT",
+			Path:        "/products/{product_id}/image",
+			Servers: []broom.Server{
+				{
+					URL:         "https://{region}.uploads.test-product-api.io",
+					Description: "Upload server.",
+					Variables: []broom.ServerVariable{
+						{
+							Name:        "region",
+							Description: "The upload region.",
+							Default:     "us",
+							Enum:        []string{"eu", "us"},
+						},
+					},
+				},
+			},
+			Parameters: broom.Parameters{
+				Path: broom.ParameterList{idParam},
+				Body: uploadImageBody,
+			},
+			BodyFormat: "multipart/form-data",
+			Bodies: []broom.Body{
+				{
+					Format:     "multipart/form-data",
+					Parameters: uploadImageBody,
+					Encoding: map[string]string{
+						"image": "image/png, image/jpeg",
+					},
+				},
+			},
+			Responses: []broom.Response{
+				{StatusCode: "204", Description: "Image uploaded."},
+			},
+		},
+	}
+
+	addSingleBodies(wantOps)
+
+	gotOps, err := broom.LoadOperations("testdata/openapi3.yaml")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if diff := cmp.Diff(wantOps, gotOps); diff != "" {
+		t.Errorf("operation mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadOperations_Swagger(t *testing.T) {
+	minPrice := 0.0
+	wantOps := broom.Operations{
+		broom.Operation{
+			ID:          "list-products",
+			Summary:     "List products",
+			Description: "Retrieves a list of products matching the specified criteria.",
+			Tags:        []string{"Products"},
+			Method:      "GET",
+			Path:        "/products",
+			Security:    []broom.SecurityRequirement{{}},
+			Parameters: broom.Parameters{
+				Query: broom.ParameterList{
+					broom.Parameter{
+						In:          "query",
+						Name:        "ids",
+						Description: "Allows filtering by ID.",
+						Style:       "form",
+						Type:        "[]string",
+					},
+					broom.Parameter{
+						In:          "query",
+						Name:        "status",
+						Description: "Allows filtering by status.",
+						Style:       "form",
+						Type:        "[]string",
+						Enum:        []string{"draft", "published"},
+					},
+					broom.Parameter{
+						In:          "query",
+						Name:        "sort",
+						Description: "Allows sorting by a single field.",
+						Style:       "form",
+						Explode:     true,
+						Type:        "string",
+						Default:     "created_at",
+					},
+				},
+			},
+			Responses: []broom.Response{
+				{
+					StatusCode:  "200",
+					Description: "Successful response.",
+					Formats:     []string{"application/json"},
+					Type:        "[]object",
+					Fields:      []string{"name", "price"},
+				},
+			},
+		},
+		broom.Operation{
+			ID:          "create-product",
+			Summary:     "Create product",
+			Description: "Creates a new product.",
+			Tags:        []string{"Products"},
+			Method:      "POST",
+			Path:        "/products",
+			Security:    []broom.SecurityRequirement{{"ApiKey"}},
+			Parameters: broom.Parameters{
 				Body: broom.ParameterList{
 					broom.Parameter{
 						In:          "body",
 						Name:        "name",
 						Description: "The product name.",
 						Type:        "string",
+						Required:    true,
 					},
 					broom.Parameter{
 						In:          "body",
-						Name:        "sku",
-						Description: "The product sku.",
-						Type:        "string",
+						Name:        "price",
+						Description: "The product price, in cents.",
+						Type:        "integer",
+						Constraints: broom.Constraints{Minimum: &minPrice},
+						Example:     "1099",
 					},
+				},
+			},
+			BodyFormat: "application/json",
+			Responses: []broom.Response{
+				{
+					StatusCode:  "201",
+					Description: "Successful response.",
+					Formats:     []string{"application/json"},
+					Type:        "object",
+					Fields:      []string{"name", "price"},
+				},
+			},
+		},
+		broom.Operation{
+			ID:          "upload-product-image",
+			Summary:     "Upload product image",
+			Description: "Uploads an image for the specified product.",
+			Tags:        []string{"Products"},
+			Method:      "PUT",
+			Path:        "/products/{product_id}/image",
+			Security:    []broom.SecurityRequirement{{"ApiKey"}},
+			Parameters: broom.Parameters{
+				Path: broom.ParameterList{
 					broom.Parameter{
-						In:          "body",
-						Name:        "description",
-						Description: "The product description.",
+						In:          "path",
+						Name:        "product_id",
+						Description: "The ID of the product.",
+						Style:       "simple",
 						Type:        "string",
+						Required:    true,
 					},
+				},
+				Body: broom.ParameterList{
 					broom.Parameter{
 						In:          "body",
-						Name:        "price",
-						Description: "The product price, in cents.",
-						Type:        "integer",
+						Name:        "image",
+						Description: "The image file.",
+						Type:        "string",
+						Format:      "binary",
+						Required:    true,
 					},
 					broom.Parameter{
 						In:          "body",
-						Name:        "currency_code",
-						Description: "The currency code.",
+						Name:        "alt",
+						Description: "The image alt text.",
 						Type:        "string",
-						Enum:        []string{"EUR", "USD"},
 					},
+				},
+			},
+			BodyFormat: "multipart/form-data",
+			Responses: []broom.Response{
+				{StatusCode: "204", Description: "Image uploaded."},
+			},
+		},
+	}
+
+	addSingleBodies(wantOps)
+
+	gotOps, err := broom.LoadOperations("testdata/swagger2.yaml")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if diff := cmp.Diff(wantOps, gotOps); diff != "" {
+		t.Errorf("operation mismatch (-want +got):\n%s", diff)
+	}
+
+	spec, err := broom.LoadSpec("testdata/swagger2.yaml")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if len(spec.Servers) != 2 {
+		t.Fatalf("got %v servers, want 2", len(spec.Servers))
+	}
+	if spec.Servers[0].URL != "https://api.test-product-api.io/v1" {
+		t.Errorf("got %v, want https://api.test-product-api.io/v1", spec.Servers[0].URL)
+	}
+	if spec.Servers[1].URL != "http://api.test-product-api.io/v1" {
+		t.Errorf("got %v, want http://api.test-product-api.io/v1", spec.Servers[1].URL)
+	}
+	apiKey := spec.Components.SecuritySchemes.GetOrZero("ApiKey")
+	if apiKey == nil || apiKey.Type != "apiKey" || apiKey.In != "header" || apiKey.Name != "X-MyApp-Key" {
+		t.Errorf("unexpected security scheme %v", apiKey)
+	}
+}
+
+func TestLoadOperations_Composition(t *testing.T) {
+	typeParam := broom.Parameter{
+		In:          "body",
+		Name:        "type",
+		Description: "The payment type.",
+		Type:        "string",
+		Required:    true,
+	}
+	amountParam := broom.Parameter{
+		In:          "body",
+		Name:        "amount",
+		Description: "The amount, in cents.",
+		Type:        "integer",
+		Required:    true,
+	}
+	paymentIDParam := broom.Parameter{
+		In:          "body",
+		Name:        "payment_id",
+		Description: "The payment ID.",
+		Type:        "string",
+	}
+	wantOps := broom.Operations{
+		broom.Operation{
+			ID:      "create-customer",
+			Summary: "Create customer",
+			Method:  "POST",
+			Path:    "/customers",
+			Parameters: broom.Parameters{
+				Body: broom.ParameterList{
 					broom.Parameter{
 						In:          "body",
-						Name:        "meta.published",
-						Description: "Whether the product is visible to customers.",
-						Type:        "boolean",
+						Name:        "name",
+						Description: "The name.",
+						Type:        "string",
+						Required:    true,
 					},
 					broom.Parameter{
 						In:          "body",
-						Name:        "meta.in_stock",
-						Description: "Whether the product is in stock.",
-						Type:        "boolean",
+						Name:        "email",
+						Description: "The customer email.",
+						Type:        "string",
+						Required:    true,
 					},
 				},
 			},
 			BodyFormat: "application/json",
 		},
 		broom.Operation{
-			ID:          "delete-product",
-			Summary:     "Delete product",
-			Description: "Deletes the specified product.",
-			Tag:         "Products",
-			Method:      "DELETE",
-			Path:        "/products/{product_id}",
-			Parameters: broom.Parameters{
-				Header: broom.ParameterList{vendorParam},
-				Path:   broom.ParameterList{idParam},
+			ID:         "create-payment",
+			Summary:    "Create payment",
+			Method:     "POST",
+			Path:       "/payments",
+			BodyFormat: "application/json",
+			BodyVariants: []broom.BodyVariant{
+				{
+					Name:        "card",
+					Description: "Pay with a card.",
+					Parameters: broom.ParameterList{
+						typeParam,
+						amountParam,
+						broom.Parameter{
+							In:          "body",
+							Name:        "card_number",
+							Description: "The card number.",
+							Type:        "string",
+							Required:    true,
+						},
+					},
+					Discriminator: "type",
+				},
+				{
+					Name:        "bank",
+					Description: "Pay with a bank transfer.",
+					Parameters: broom.ParameterList{
+						typeParam,
+						amountParam,
+						broom.Parameter{
+							In:          "body",
+							Name:        "iban",
+							Description: "The IBAN.",
+							Type:        "string",
+						},
+					},
+					Discriminator: "type",
+				},
+			},
+		},
+		broom.Operation{
+			ID:         "create-refund",
+			Summary:    "Create refund",
+			Method:     "POST",
+			Path:       "/refunds",
+			BodyFormat: "application/json",
+			BodyVariants: []broom.BodyVariant{
+				{
+					Name:       "full-refund",
+					Parameters: broom.ParameterList{paymentIDParam},
+				},
+				{
+					Name: "partial-refund",
+					Parameters: broom.ParameterList{
+						paymentIDParam,
+						broom.Parameter{
+							In:          "body",
+							Name:        "amount",
+							Description: "The amount, in cents.",
+							Type:        "integer",
+						},
+					},
+				},
 			},
 		},
 	}
 
-	gotOps, err := broom.LoadOperations("testdata/openapi3.yaml")
+	addSingleBodies(wantOps)
+
+	gotOps, err := broom.LoadOperations("testdata/payments.yaml")
 	if err != nil {
 		t.Errorf("unexpected error %v", err)
 	}
@@ -233,3 +717,190 @@ func TestLoadOperations(t *testing.T) {
 		t.Errorf("operation mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestLoadOperations_GeneratedIDs(t *testing.T) {
+	ops, err := broom.LoadOperations("testdata/ids.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	gotIDs := make([]string, 0, len(ops))
+	for _, op := range ops {
+		gotIDs = append(gotIDs, op.ID)
+	}
+	wantIDs := []string{
+		"get-products",
+		"post-products",
+		"get-products-product-id",
+		"get-products-product-id-2",
+		"delete-v2-product-images-image-id",
+		"get-users",
+		"get-users-2",
+	}
+	if diff := cmp.Diff(wantIDs, gotIDs); diff != "" {
+		t.Errorf("ID mismatch (-want +got):\n%s", diff)
+	}
+
+	// IDs defined by the spec can clash once kebab-cased.
+	// The first operation keeps the ID, the others get a suffix.
+	spec, err := os.ReadFile("testdata/ids.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec = bytes.Replace(spec, []byte("summary: Create product"), []byte("summary: Create product\n      operationId: get-users"), 1)
+	filename := t.TempDir() + "/ids.yaml"
+	os.WriteFile(filename, spec, 0644)
+	ops, err = broom.LoadOperations(filename)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	gotIDs = gotIDs[:0]
+	for _, op := range ops {
+		gotIDs = append(gotIDs, op.ID)
+	}
+	wantIDs = []string{
+		"get-products",
+		"get-users",
+		"get-products-product-id",
+		"get-products-product-id-2",
+		"delete-v2-product-images-image-id",
+		"get-users-2",
+		"get-users-3",
+	}
+	if diff := cmp.Diff(wantIDs, gotIDs); diff != "" {
+		t.Errorf("ID mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadOperations_Nullable(t *testing.T) {
+	ops, err := broom.LoadOperations("testdata/openapi31.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	op, ok := ops.ByID("update-user")
+	if !ok {
+		t.Fatal("update-user operation not found")
+	}
+	wantBody := broom.ParameterList{
+		broom.Parameter{
+			In:          "body",
+			Name:        "nickname",
+			Description: "The nickname, or null to remove it.",
+			Type:        "string",
+			Nullable:    true,
+		},
+		broom.Parameter{
+			In:          "body",
+			Name:        "age",
+			Description: "The age.",
+			Type:        "integer",
+			Nullable:    true,
+		},
+		broom.Parameter{
+			In:          "body",
+			Name:        "email",
+			Description: "The email address.",
+			Type:        "string",
+		},
+	}
+	if diff := cmp.Diff(wantBody, op.Parameters.Body); diff != "" {
+		t.Errorf("body parameter mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadOperations_RecursiveBody(t *testing.T) {
+	ops, err := broom.LoadOperations("testdata/recursive.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	op, ok := ops.ByID("create-category")
+	if !ok {
+		t.Fatal("create-category operation not found")
+	}
+	// Recursive schemas are not expanded.
+	var got []string
+	for _, p := range op.Parameters.Body {
+		got = append(got, p.Name+" "+p.Type)
+	}
+	want := []string{"name string", "parent object", "children []object", "tree [][]array"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("body parameter mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadOperations_EmptyBody(t *testing.T) {
+	ops, err := broom.LoadOperations("testdata/empty-body.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	op, ok := ops.ByID("archive-product")
+	if !ok {
+		t.Fatal("archive-product operation not found")
+	}
+	if op.HasBody() {
+		t.Error("got a body, want none")
+	}
+	if op.BodyFormat != "" {
+		t.Errorf(`got %q, want ""`, op.BodyFormat)
+	}
+}
+
+func TestLoadOperations_Security(t *testing.T) {
+	ops, err := broom.LoadOperations("testdata/security.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	tests := []struct {
+		opID string
+		want []broom.SecurityRequirement
+	}{
+		{"list-plans", []broom.SecurityRequirement{{}}},
+		{"list-products", []broom.SecurityRequirement{{}, {"BearerAuth"}}},
+		{"list-orders", []broom.SecurityRequirement{{"BearerAuth"}}},
+		{"list-reports", []broom.SecurityRequirement{{"ApiKey", "StoreId"}, {"BearerAuth"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.opID, func(t *testing.T) {
+			op, ok := ops.ByID(tt.opID)
+			if !ok {
+				t.Fatalf("%v operation not found", tt.opID)
+			}
+			if diff := cmp.Diff(tt.want, op.Security); diff != "" {
+				t.Errorf("security mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadSpecContents(t *testing.T) {
+	contents, err := broom.LoadSpecContents("testdata/openapi3.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	wantOps, _ := broom.LoadOperations("testdata/openapi3.yaml")
+	if diff := cmp.Diff(wantOps, contents.Operations); diff != "" {
+		t.Errorf("operation mismatch (-want +got):\n%s", diff)
+	}
+	wantTags := []broom.Tag{
+		{Name: "Products", Description: "Manage products."},
+		{Name: "Images", Description: "Manage product images."},
+	}
+	if diff := cmp.Diff(wantTags, contents.Tags); diff != "" {
+		t.Errorf("tag mismatch (-want +got):\n%s", diff)
+	}
+	wantServers := []broom.Server{{URL: "https://api.test-product-api.io"}}
+	if diff := cmp.Diff(wantServers, contents.Servers); diff != "" {
+		t.Errorf("server mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// addSingleBodies fills Bodies for expected operations with a single body format,
+// which list it in Bodies as well.
+func addSingleBodies(ops broom.Operations) {
+	for i, op := range ops {
+		if op.BodyFormat != "" && op.Bodies == nil {
+			ops[i].Bodies = []broom.Body{
+				{Format: op.BodyFormat, Parameters: op.Parameters.Body, Variants: op.BodyVariants},
+			}
+		}
+	}
+}
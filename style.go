@@ -0,0 +1,183 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// defaultStyle returns the default serialization style for the given parameter location.
+func defaultStyle(in string) string {
+	if in == "query" || in == "cookie" {
+		return "form"
+	}
+	return "simple"
+}
+
+// pathValue serializes the given path parameter value according to the parameter style.
+//
+// Supported styles: simple (default), label, matrix.
+func (p Parameter) pathValue(value string) (string, error) {
+	items, props, err := p.splitValues([]string{value})
+	if err != nil {
+		return "", err
+	}
+	name := url.PathEscape(p.Name)
+	isComposite := p.isArray() || p.isObject()
+	parts := joinParts(items, props, p.Explode, url.PathEscape)
+
+	switch p.Style {
+	case "label":
+		sep := ","
+		if p.Explode && isComposite {
+			sep = "."
+		}
+		return "." + strings.Join(parts, sep), nil
+	case "matrix":
+		if !p.Explode || !isComposite {
+			return ";" + name + "=" + strings.Join(parts, ","), nil
+		}
+		if p.isArray() {
+			for i, part := range parts {
+				parts[i] = name + "=" + part
+			}
+		}
+		return ";" + strings.Join(parts, ";"), nil
+	default:
+		return strings.Join(parts, ","), nil
+	}
+}
+
+// queryString serializes the given query parameter values according to the parameter style.
+//
+// Supported styles: form (default), spaceDelimited, pipeDelimited, deepObject.
+// Returns the encoded query string, without the leading "?".
+func (p Parameter) queryString(values []string) (string, error) {
+	items, props, err := p.splitValues(values)
+	if err != nil {
+		return "", err
+	}
+	escape := url.QueryEscape
+	if p.AllowReserved {
+		escape = escapeReserved
+	}
+	name := url.QueryEscape(p.Name)
+	if p.Style == "deepObject" && p.isObject() {
+		pairs := make([]string, 0, len(props))
+		for _, prop := range props {
+			pairs = append(pairs, name+"["+escape(prop[0])+"]="+escape(prop[1]))
+		}
+		return strings.Join(pairs, "&"), nil
+	}
+	parts := joinParts(items, props, p.Explode, escape)
+	if p.Explode || !(p.isArray() || p.isObject()) {
+		// Exploded object properties are already in the key=value format.
+		if !p.isObject() {
+			for i, part := range parts {
+				parts[i] = name + "=" + part
+			}
+		}
+		return strings.Join(parts, "&"), nil
+	}
+	sep := ","
+	switch p.Style {
+	case "spaceDelimited":
+		sep = "%20"
+	case "pipeDelimited":
+		sep = "|"
+	}
+
+	return name + "=" + strings.Join(parts, sep), nil
+}
+
+// headerValue serializes the given header parameter value using the simple style.
+func (p Parameter) headerValue(value string) (string, error) {
+	items, props, err := p.splitValues([]string{value})
+	if err != nil {
+		return "", err
+	}
+	parts := joinParts(items, props, p.Explode, func(s string) string { return s })
+
+	return strings.Join(parts, ","), nil
+}
+
+// splitValues splits the given values into array items or object properties.
+//
+// Array items are separated by commas (e.g. "red,green"), as are object
+// properties, which use the key=value format (e.g. "R=100,G=200").
+// Values of other types are returned as-is.
+func (p Parameter) splitValues(values []string) (items []string, props [][2]string, err error) {
+	switch {
+	case p.isArray():
+		for _, v := range values {
+			items = append(items, strings.Split(v, ",")...)
+		}
+	case p.isObject():
+		for _, v := range values {
+			for _, prop := range strings.Split(v, ",") {
+				key, value, ok := strings.Cut(prop, "=")
+				if !ok {
+					return nil, nil, fmt.Errorf("%v: %q is not a valid object, expected key=value pairs", p.Name, v)
+				}
+				props = append(props, [2]string{key, value})
+			}
+		}
+	default:
+		items = values
+	}
+
+	return items, props, nil
+}
+
+// isArray returns whether the parameter is an array.
+func (p Parameter) isArray() bool {
+	return strings.HasPrefix(p.Type, "[]")
+}
+
+// isObject returns whether the parameter is an object.
+func (p Parameter) isObject() bool {
+	return p.Type == "object"
+}
+
+// joinParts escapes the given array items or object properties.
+//
+// Object properties become key=value pairs when exploded,
+// and a flat list of keys and values otherwise (R,100,G,200).
+func joinParts(items []string, props [][2]string, explode bool, escape func(string) string) []string {
+	parts := make([]string, 0, len(items)+len(props)*2)
+	for _, item := range items {
+		parts = append(parts, escape(item))
+	}
+	for _, prop := range props {
+		if explode {
+			parts = append(parts, escape(prop[0])+"="+escape(prop[1]))
+		} else {
+			parts = append(parts, escape(prop[0]), escape(prop[1]))
+		}
+	}
+
+	return parts
+}
+
+// escapeReserved escapes the given query value, keeping reserved characters as-is.
+//
+// Used for parameters with allowReserved. The "#" character is always escaped,
+// since it would otherwise start the URL fragment.
+func escapeReserved(s string) string {
+	const reserved = ":/?[]@!$&'()*+,;="
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		isUnreserved := ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte("-._~", c) != -1
+		if isUnreserved || strings.IndexByte(reserved, c) != -1 {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,407 @@
+// Copyright (c) 2021 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package broom
+
+import (
+	"net/url"
+	"slices"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v2 "github.com/pb33f/libopenapi/datamodel/high/v2"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// convertSwagger converts a Swagger 2.0 specification into an OpenAPI 3 one.
+//
+// Only the parts of the specification used by Broom are converted:
+// servers, security schemes, tags, and operations.
+//
+// The root node is used to find operations with an empty list of security
+// requirements (security: []), which the Swagger model represents as nil.
+func convertSwagger(filename string, swagger v2.Swagger, root *yaml.Node) v3.Document {
+	doc := v3.Document{
+		Version:  swagger.Swagger,
+		Info:     swagger.Info,
+		Servers:  convertSwaggerServers(filename, swagger),
+		Security: swagger.Security,
+		Tags:     swagger.Tags,
+		Components: &v3.Components{
+			SecuritySchemes: orderedmap.New[string, *v3.SecurityScheme](),
+		},
+	}
+	if swagger.SecurityDefinitions != nil {
+		for pair := orderedmap.First(swagger.SecurityDefinitions.Definitions); pair != nil; pair = pair.Next() {
+			doc.Components.SecuritySchemes.Set(pair.Key(), convertSwaggerSecurityScheme(pair.Value()))
+		}
+	}
+	if swagger.Paths == nil {
+		return doc
+	}
+
+	doc.Paths = &v3.Paths{
+		PathItems: orderedmap.New[string, *v3.PathItem](),
+	}
+	publicOps := findPublicSwaggerOperations(root)
+	for pair := orderedmap.First(swagger.Paths.PathItems); pair != nil; pair = pair.Next() {
+		swaggerPathItem := pair.Value()
+		pathItem := &v3.PathItem{}
+		for _, param := range swaggerPathItem.Parameters {
+			if param.In != "body" && param.In != "formData" {
+				pathItem.Parameters = append(pathItem.Parameters, convertSwaggerParameter(param))
+			}
+		}
+		convertOp := func(method string, swaggerOp *v2.Operation) *v3.Operation {
+			if swaggerOp == nil {
+				return nil
+			}
+			op := convertSwaggerOperation(swagger, swaggerPathItem.Parameters, swaggerOp)
+			if publicOps[pair.Key()+" "+method] {
+				op.Security = []*base.SecurityRequirement{}
+			}
+			return op
+		}
+		pathItem.Get = convertOp("get", swaggerPathItem.Get)
+		pathItem.Post = convertOp("post", swaggerPathItem.Post)
+		pathItem.Put = convertOp("put", swaggerPathItem.Put)
+		pathItem.Patch = convertOp("patch", swaggerPathItem.Patch)
+		pathItem.Delete = convertOp("delete", swaggerPathItem.Delete)
+		pathItem.Head = convertOp("head", swaggerPathItem.Head)
+		pathItem.Options = convertOp("options", swaggerPathItem.Options)
+
+		doc.Paths.PathItems.Set(pair.Key(), pathItem)
+	}
+
+	return doc
+}
+
+// findPublicSwaggerOperations finds operations with an empty list of security requirements.
+//
+// Returns a map keyed by path and method (e.g. "/products get").
+func findPublicSwaggerOperations(root *yaml.Node) map[string]bool {
+	publicOps := make(map[string]bool)
+	if root == nil || len(root.Content) == 0 {
+		return publicOps
+	}
+	paths := mappingValue(root.Content[0], "paths")
+	if paths == nil {
+		return publicOps
+	}
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		pathItem := paths.Content[i+1]
+		if pathItem.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(pathItem.Content); j += 2 {
+			security := mappingValue(pathItem.Content[j+1], "security")
+			if security != nil && security.Kind == yaml.SequenceNode && len(security.Content) == 0 {
+				publicOps[paths.Content[i].Value+" "+pathItem.Content[j].Value] = true
+			}
+		}
+	}
+
+	return publicOps
+}
+
+// mappingValue returns the value of the given key in a YAML mapping node.
+//
+// Returns nil if the node is not a mapping, or if the key was not found.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// convertSwaggerServers converts the Swagger host, basePath, and schemes into servers.
+//
+// As per the Swagger spec, a missing host or scheme is taken from the specification's
+// own URL, when available.
+func convertSwaggerServers(filename string, swagger v2.Swagger) []*v3.Server {
+	host := swagger.Host
+	schemes := swagger.Schemes
+	if IsURL(filename) {
+		if specURL, err := url.Parse(filename); err == nil {
+			if host == "" {
+				host = specURL.Host
+			}
+			if len(schemes) == 0 {
+				schemes = []string{specURL.Scheme}
+			}
+		}
+	}
+	if host == "" {
+		return nil
+	}
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	// Prefer https over http, since the schemes are not ordered by preference.
+	if slices.Contains(schemes, "https") {
+		schemes = append([]string{"https"}, slices.DeleteFunc(slices.Clone(schemes), func(s string) bool {
+			return s == "https"
+		})...)
+	}
+	servers := make([]*v3.Server, 0, len(schemes))
+	for _, scheme := range schemes {
+		if scheme != "http" && scheme != "https" {
+			continue
+		}
+		servers = append(servers, &v3.Server{
+			URL: scheme + "://" + host + swagger.BasePath,
+		})
+	}
+
+	return servers
+}
+
+// convertSwaggerSecurityScheme converts a Swagger security scheme.
+func convertSwaggerSecurityScheme(swaggerScheme *v2.SecurityScheme) *v3.SecurityScheme {
+	scheme := &v3.SecurityScheme{
+		Type:        swaggerScheme.Type,
+		Description: swaggerScheme.Description,
+		Name:        swaggerScheme.Name,
+		In:          swaggerScheme.In,
+	}
+	switch swaggerScheme.Type {
+	case "basic":
+		scheme.Type = "http"
+		scheme.Scheme = "basic"
+	case "oauth2":
+		flow := &v3.OAuthFlow{
+			AuthorizationUrl: swaggerScheme.AuthorizationUrl,
+			TokenUrl:         swaggerScheme.TokenUrl,
+			Scopes:           orderedmap.New[string, string](),
+		}
+		if swaggerScheme.Scopes != nil {
+			for pair := orderedmap.First(swaggerScheme.Scopes.Values); pair != nil; pair = pair.Next() {
+				flow.Scopes.Set(pair.Key(), pair.Value())
+			}
+		}
+		scheme.Flows = &v3.OAuthFlows{}
+		switch swaggerScheme.Flow {
+		case "implicit":
+			scheme.Flows.Implicit = flow
+		case "password":
+			scheme.Flows.Password = flow
+		case "application":
+			scheme.Flows.ClientCredentials = flow
+		case "accessCode":
+			scheme.Flows.AuthorizationCode = flow
+		}
+	}
+
+	return scheme
+}
+
+// convertSwaggerOperation converts a Swagger operation.
+//
+// Body and formData parameters are converted into a request body,
+// using the media types listed in "consumes".
+func convertSwaggerOperation(swagger v2.Swagger, pathParams []*v2.Parameter, swaggerOp *v2.Operation) *v3.Operation {
+	deprecated := swaggerOp.Deprecated
+	op := &v3.Operation{
+		Tags:        swaggerOp.Tags,
+		Summary:     swaggerOp.Summary,
+		Description: swaggerOp.Description,
+		OperationId: swaggerOp.OperationId,
+		Deprecated:  &deprecated,
+		Security:    swaggerOp.Security,
+	}
+	consumes := swaggerOp.Consumes
+	if len(consumes) == 0 {
+		consumes = swagger.Consumes
+	}
+	produces := swaggerOp.Produces
+	if len(produces) == 0 {
+		produces = swagger.Produces
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+
+	// The remaining path-level parameters are converted as part of the path item.
+	var bodyParam *v2.Parameter
+	var formParams []*v2.Parameter
+	for _, param := range pathParams {
+		switch param.In {
+		case "body":
+			bodyParam = param
+		case "formData":
+			formParams = append(formParams, param)
+		}
+	}
+	for _, param := range swaggerOp.Parameters {
+		switch param.In {
+		case "body":
+			bodyParam = param
+		case "formData":
+			formParams = append(formParams, param)
+		default:
+			op.Parameters = append(op.Parameters, convertSwaggerParameter(param))
+		}
+	}
+	if bodyParam != nil && bodyParam.Schema != nil {
+		formats := consumes
+		if len(formats) == 0 {
+			formats = []string{"application/json"}
+		}
+		op.RequestBody = &v3.RequestBody{
+			Description: bodyParam.Description,
+			Content:     orderedmap.New[string, *v3.MediaType](),
+			Required:    bodyParam.Required,
+		}
+		for _, format := range formats {
+			op.RequestBody.Content.Set(format, &v3.MediaType{Schema: bodyParam.Schema})
+		}
+	} else if len(formParams) > 0 {
+		schema := &base.Schema{
+			Type:       []string{"object"},
+			Properties: orderedmap.New[string, *base.SchemaProxy](),
+		}
+		hasFile := false
+		for _, param := range formParams {
+			schema.Properties.Set(param.Name, base.CreateSchemaProxy(newSchemaFromSwaggerParameter(param)))
+			if getBool(param.Required) {
+				schema.Required = append(schema.Required, param.Name)
+			}
+			if param.Type == "file" {
+				hasFile = true
+			}
+		}
+		formats := make([]string, 0, 2)
+		for _, format := range consumes {
+			if format == "multipart/form-data" || format == "application/x-www-form-urlencoded" {
+				formats = append(formats, format)
+			}
+		}
+		if len(formats) == 0 {
+			if hasFile {
+				formats = append(formats, "multipart/form-data")
+			} else {
+				formats = append(formats, "application/x-www-form-urlencoded")
+			}
+		}
+		op.RequestBody = &v3.RequestBody{
+			Content: orderedmap.New[string, *v3.MediaType](),
+		}
+		for _, format := range formats {
+			op.RequestBody.Content.Set(format, &v3.MediaType{Schema: base.CreateSchemaProxy(schema)})
+		}
+	}
+
+	if swaggerOp.Responses != nil {
+		convertResponse := func(swaggerResp *v2.Response) *v3.Response {
+			resp := &v3.Response{Description: swaggerResp.Description}
+			if swaggerResp.Schema != nil {
+				resp.Content = orderedmap.New[string, *v3.MediaType]()
+				for _, format := range produces {
+					resp.Content.Set(format, &v3.MediaType{Schema: swaggerResp.Schema})
+				}
+			}
+			return resp
+		}
+		op.Responses = &v3.Responses{
+			Codes: orderedmap.New[string, *v3.Response](),
+		}
+		for pair := orderedmap.First(swaggerOp.Responses.Codes); pair != nil; pair = pair.Next() {
+			op.Responses.Codes.Set(pair.Key(), convertResponse(pair.Value()))
+		}
+		if swaggerOp.Responses.Default != nil {
+			op.Responses.Default = convertResponse(swaggerOp.Responses.Default)
+		}
+	}
+
+	return op
+}
+
+// convertSwaggerParameter converts a Swagger header, path, or query parameter.
+func convertSwaggerParameter(swaggerParam *v2.Parameter) *v3.Parameter {
+	param := &v3.Parameter{
+		Name:        swaggerParam.Name,
+		In:          swaggerParam.In,
+		Description: swaggerParam.Description,
+		Required:    swaggerParam.Required,
+		Schema:      base.CreateSchemaProxy(newSchemaFromSwaggerParameter(swaggerParam)),
+	}
+	// Map the collection format to the equivalent style.
+	if swaggerParam.Type == "array" {
+		explode := false
+		switch swaggerParam.CollectionFormat {
+		case "", "csv":
+			if param.In == "query" {
+				param.Style = "form"
+			} else {
+				param.Style = "simple"
+			}
+		case "multi":
+			param.Style = "form"
+			explode = true
+		case "ssv":
+			param.Style = "spaceDelimited"
+		case "pipes":
+			param.Style = "pipeDelimited"
+		}
+		param.Explode = &explode
+	}
+
+	return param
+}
+
+// newSchemaFromSwaggerParameter creates a new schema from the given Swagger parameter.
+func newSchemaFromSwaggerParameter(swaggerParam *v2.Parameter) *base.Schema {
+	schema := newSchemaFromSwaggerType(swaggerParam.Type, swaggerParam.Format, swaggerParam.Items)
+	schema.Description = swaggerParam.Description
+	schema.Enum = swaggerParam.Enum
+	schema.Default = swaggerParam.Default
+	schema.Pattern = swaggerParam.Pattern
+	if swaggerParam.Maximum != nil {
+		maximum := float64(*swaggerParam.Maximum)
+		schema.Maximum = &maximum
+	}
+	if swaggerParam.Minimum != nil {
+		minimum := float64(*swaggerParam.Minimum)
+		schema.Minimum = &minimum
+	}
+	if swaggerParam.MaxLength != nil {
+		maxLength := int64(*swaggerParam.MaxLength)
+		schema.MaxLength = &maxLength
+	}
+	if swaggerParam.MinLength != nil {
+		minLength := int64(*swaggerParam.MinLength)
+		schema.MinLength = &minLength
+	}
+
+	return schema
+}
+
+// newSchemaFromSwaggerType creates a new schema from the given Swagger type information.
+//
+// The "file" type has no OpenAPI 3 equivalent, it becomes a binary string.
+func newSchemaFromSwaggerType(schemaType string, format string, items *v2.Items) *base.Schema {
+	if schemaType == "file" {
+		schemaType = "string"
+		format = "binary"
+	}
+	schema := &base.Schema{
+		Type:   []string{schemaType},
+		Format: format,
+	}
+	if schemaType == "array" && items != nil {
+		itemSchema := newSchemaFromSwaggerType(items.Type, items.Format, items.Items)
+		itemSchema.Enum = items.Enum
+		itemSchema.Default = items.Default
+		schema.Items = &base.DynamicValue[*base.SchemaProxy, bool]{
+			A: base.CreateSchemaProxy(itemSchema),
+		}
+	}
+
+	return schema
+}